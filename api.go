@@ -0,0 +1,135 @@
+package goblue
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// HttpClientFunc adapts a function to the HttpClient interface.
+type HttpClientFunc func(req *http.Request) (*http.Response, error)
+
+func (f HttpClientFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Middleware wraps every request sent to the api. It can inspect or modify
+// the request before passing it on and the response afterwards.
+type Middleware func(next HttpClient) HttpClient
+
+// WithMiddleware adds middleware to the request pipeline. The first
+// middleware passed sees the requests first.
+func WithMiddleware(m ...Middleware) ClientOptions {
+	return func(c *Client) error {
+		c.middleware = append(c.middleware, m...)
+		return nil
+	}
+}
+
+// chain wraps the client with the given middleware, the first one being the
+// outermost.
+func chain(h HttpClient, middleware ...Middleware) HttpClient {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+	return h
+}
+
+// stampMiddleware adds a fresh stamp of the brand to every request.
+func stampMiddleware(b Brand) Middleware {
+	return func(next HttpClient) HttpClient {
+		return HttpClientFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get("Stamp") == "" {
+				stamp, err := GetStampFromList(b)
+				if err != nil {
+					return nil, err
+				}
+				req.Header.Set("Stamp", stamp)
+			}
+			return next.Do(req)
+		})
+	}
+}
+
+// spaHeaders returns the headers required by the vehicle api for the
+// session, authorized by the given token.
+func (a *auth) spaHeaders(s session, token string) map[string]string {
+	return map[string]string{
+		"Authorization":       token,
+		"ccsp-device-id":      s.DeviceID,
+		"ccsp-application-id": a.CCSPApplicationID,
+		"offset":              "1",
+		"User-Agent":          a.UserAgent,
+	}
+}
+
+// newJSONRequest builds a request carrying the json encoded payload. A nil
+// payload sends no body.
+func newJSONRequest(ctx context.Context, method, uri string, payload interface{}, headers ...map[string]string) (*http.Request, error) {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(data)
+		headers = append(headers, map[string]string{
+			"Content-type": "application/json;charset=UTF-8",
+		})
+	}
+	return newHttpRequest(ctx, method, uri, body, headers...)
+}
+
+// doJSON sends the request and decodes the resMsg of the response envelope
+// into out. It returns the msgId of the response. A nil out discards the
+// response message.
+func doJSON(h HttpClient, req *http.Request, out interface{}) (string, error) {
+	resp, err := h.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if err := checkResponse(resp.StatusCode, body); err != nil {
+		return "", err
+	}
+
+	msg := struct {
+		Resmsg json.RawMessage `json:"resMsg"`
+		Msgid  string          `json:"msgId"`
+	}{}
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return "", err
+	}
+
+	if out == nil || len(msg.Resmsg) == 0 {
+		return msg.Msgid, nil
+	}
+	return msg.Msgid, json.Unmarshal(msg.Resmsg, out)
+}
+
+// doRawJSON sends the request and decodes the whole response body into out.
+// It serves the oauth endpoints, which do not wrap their responses in the
+// envelope of the vehicle api.
+func doRawJSON(h HttpClient, req *http.Request, out interface{}) error {
+	resp, err := h.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return checkResponse(resp.StatusCode, body)
+	}
+	return json.Unmarshal(body, out)
+}
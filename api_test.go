@@ -0,0 +1,42 @@
+package goblue
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDoRawJSON(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"retCode": "F", "resCode": "4003", "resMsg": "invalid pin"}`))
+			return
+		}
+		w.Write([]byte(`{"token_type": "Bearer", "access_token": "abc"}`))
+	}))
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/token", nil)
+	var tokens struct {
+		TokenType   string `json:"token_type"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := doRawJSON(srv.Client(), req, &tokens); err != nil {
+		t.Fatal(err)
+	}
+	if tokens.TokenType != "Bearer" || tokens.AccessToken != "abc" {
+		t.Errorf("decoded %+v", tokens)
+	}
+
+	req, _ = http.NewRequest(http.MethodPost, srv.URL+"/fail", nil)
+	err := doRawJSON(srv.Client(), req, &tokens)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("got %v, want api error with status 400", err)
+	}
+	if !errors.Is(err, ErrInvalidPIN) {
+		t.Errorf("%v does not match ErrInvalidPIN", err)
+	}
+}
@@ -0,0 +1,84 @@
+package goblue_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/frzifus/goblue"
+	"github.com/frzifus/goblue/bluelinktest"
+)
+
+// TestConcurrentAuthentication is meant to run with -race. Vehicles share
+// the session of their client, a renewed session has to reach them.
+func TestConcurrentAuthentication(t *testing.T) {
+	srv := bluelinktest.NewServer(bluelinktest.Credentials{Username: "user", Password: "secret", PIN: "1234"})
+	defer srv.Close()
+	srv.AddVehicle(bluelinktest.Vehicle{ID: "00000000-0000-0000-0000-0000000000c1", VIN: "VIN-1", Type: "EV"})
+	srv.AddVehicle(bluelinktest.Vehicle{ID: "00000000-0000-0000-0000-0000000000c2", VIN: "VIN-2", Type: "GN"})
+
+	c, err := goblue.NewClient(srv.Config(goblue.BrandKia), srv.ClientOptions()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Authenticate(); err != nil {
+		t.Fatal(err)
+	}
+	vehicles, err := c.Vehicles()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 64)
+	run := func(f func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := f(); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	for i := 0; i < 4; i++ {
+		run(c.Authenticate)
+		run(func() error { _, err := c.Vehicles(); return err })
+		for _, v := range vehicles {
+			v := v
+			run(func() error { _, err := v.Status(); return err })
+		}
+	}
+	for _, v := range vehicles {
+		run(v.Unlock)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	// force a re-authentication, the existing vehicles have to use the new
+	// access token and request a new control token
+	srv.ExpireTokens()
+	if _, err := vehicles[0].Status(); !errors.Is(err, goblue.ErrNotAuthenticated) {
+		t.Fatalf("status with an expired token: got %v, want ErrNotAuthenticated", err)
+	}
+
+	wg = sync.WaitGroup{}
+	errs = make(chan error, 64)
+	run(c.Authenticate)
+	wg.Wait()
+	for _, v := range vehicles {
+		v := v
+		run(func() error { _, err := v.Status(); return err })
+		run(v.Lock)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("after re-authentication: %v", err)
+	}
+	if n := len(srv.Commands()); n != 2*len(vehicles) {
+		t.Errorf("server received %d commands, want %d", n, 2*len(vehicles))
+	}
+}
@@ -0,0 +1,638 @@
+// Package bluelinktest provides a fake Bluelink api to test goblue and code
+// built on top of it without a network connection or real credentials.
+//
+//	srv := bluelinktest.NewServer(bluelinktest.Credentials{
+//		Username: "user", Password: "secret", PIN: "1234",
+//	})
+//	defer srv.Close()
+//	srv.AddVehicle(bluelinktest.Vehicle{ID: "id", VIN: "vin", Type: "EV"})
+//
+//	client, err := goblue.NewClient(srv.Config(goblue.BrandKia), srv.ClientOptions()...)
+package bluelinktest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/frzifus/goblue"
+)
+
+const (
+	deviceID           = "00000000-0000-0000-0000-000000000001"
+	authCode           = "test-authorization-code"
+	accessTokenPrefix  = "test-access-token-"
+	controlTokenPrefix = "test-control-token-"
+
+	resCodeOk  = "0000"
+	timeLayout = "20060102150405"
+)
+
+// apiLocation is the zone the api reports times in.
+var apiLocation, _ = time.LoadLocation("Europe/Berlin")
+
+// Endpoint names a group of api routes served by the Server.
+type Endpoint string
+
+const (
+	EndpointDeviceID  Endpoint = "deviceid"
+	EndpointAuthorize Endpoint = "authorize"
+	EndpointLanguage  Endpoint = "language"
+	EndpointSignIn    Endpoint = "signin"
+	EndpointToken     Endpoint = "token"
+	EndpointVehicles  Endpoint = "vehicles"
+	EndpointStatus    Endpoint = "status"
+	EndpointPin       Endpoint = "pin"
+	EndpointControl   Endpoint = "control"
+	EndpointRecords   Endpoint = "records"
+	EndpointLocation  Endpoint = "location"
+)
+
+// Credentials are the account data accepted by the Server.
+type Credentials struct {
+	Username string
+	Password string
+	PIN      string
+}
+
+// Failure is an error response injected into the next calls of an endpoint.
+type Failure struct {
+	StatusCode int
+	ResCode    string
+	Message    string
+	// RetryAfter is sent as Retry-After header in seconds if set.
+	RetryAfter int
+}
+
+var (
+	// FailureRateLimited is returned by the api once the daily quota is
+	// exhausted.
+	FailureRateLimited = Failure{StatusCode: http.StatusTooManyRequests, ResCode: "5091", Message: "Exceeds number of requests"}
+	// FailureVehicleAsleep is returned if the vehicle does not respond.
+	FailureVehicleAsleep = Failure{StatusCode: http.StatusOK, ResCode: "4081", Message: "Request timeout"}
+	// FailureUnavailable is a transient server side error.
+	FailureUnavailable = Failure{StatusCode: http.StatusServiceUnavailable, ResCode: "5031", Message: "Service unavailable"}
+)
+
+// State is the scriptable state of a vehicle.
+type State struct {
+	Locked      bool
+	Charging    bool
+	PluggedIn   bool
+	SoC         int
+	Range       int // km
+	TargetSoCAC int
+	TargetSoCDC int
+	Windows     goblue.Windows
+	ClimateOn   bool
+	Odometer    float64 // km
+	Location    goblue.Location
+	UpdatedAt   time.Time
+}
+
+// Vehicle is a vehicle registered at the Server.
+type Vehicle struct {
+	ID   string
+	VIN  string
+	Name string
+	// Type is the vehicle type as reported by the api: EV, PHEV, HV or GN.
+	Type string
+	CCS2 bool
+	// Features is the feature list reported by the vehicles endpoint.
+	Features []string
+	State    State
+}
+
+// Command is a remote command received by the Server.
+type Command struct {
+	VehicleID string
+	Name      string
+	Payload   map[string]interface{}
+}
+
+// Server is a fake Bluelink api. All methods are safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	mu            sync.Mutex
+	credentials   Credentials
+	vehicles      []*Vehicle
+	failures      map[Endpoint][]Failure
+	commands      []Command
+	records       map[string][]map[string]interface{}
+	commandResult string
+	msgID         int
+	tokens        int
+	accessTokens  map[string]bool // valid bearer tokens
+	controlTokens map[string]bool
+}
+
+// NewServer starts a Server accepting the given credentials. It has to be
+// closed after use.
+func NewServer(c Credentials) *Server {
+	s := &Server{
+		credentials:   c,
+		failures:      map[Endpoint][]Failure{},
+		records:       map[string][]map[string]interface{}{},
+		commandResult: "success",
+		accessTokens:  map[string]bool{},
+		controlTokens: map[string]bool{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Config returns a client configuration for the accepted credentials.
+func (s *Server) Config(b goblue.Brand) goblue.Config {
+	return goblue.Config{
+		Username: s.credentials.Username,
+		Password: s.credentials.Password,
+		Pin:      s.credentials.PIN,
+		Brand:    b,
+		Region:   goblue.RegionEU,
+	}
+}
+
+// ClientOptions returns the options pointing a client to the Server.
+func (s *Server) ClientOptions() []goblue.ClientOptions {
+	return []goblue.ClientOptions{
+		goblue.WithBaseURL(s.URL),
+		goblue.WithTransport(s.Client().Transport),
+	}
+}
+
+// AddVehicle registers a vehicle with the account.
+func (s *Server) AddVehicle(v Vehicle) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if v.State.UpdatedAt.IsZero() {
+		v.State.UpdatedAt = time.Now()
+	}
+	s.vehicles = append(s.vehicles, &v)
+}
+
+// SetState replaces the state of the vehicle with the given id.
+func (s *Server) SetState(id string, st State) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if v := s.vehicle(id); v != nil {
+		v.State = st
+	}
+}
+
+// State returns the state of the vehicle with the given id.
+func (s *Server) State(id string) (State, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if v := s.vehicle(id); v != nil {
+		return v.State, true
+	}
+	return State{}, false
+}
+
+// Fail lets the next n calls of the endpoint fail.
+func (s *Server) Fail(e Endpoint, f Failure, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < n; i++ {
+		s.failures[e] = append(s.failures[e], f)
+	}
+}
+
+// SetCommandResult sets the result reported for following commands. The api
+// knows success, fail and non-response.
+func (s *Server) SetCommandResult(result string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.commandResult = result
+}
+
+// Commands returns all remote commands received so far.
+func (s *Server) Commands() []Command {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Command(nil), s.commands...)
+}
+
+// ExpireTokens invalidates all access and control tokens issued so far, as
+// if they had expired. Clients have to authenticate again.
+func (s *Server) ExpireTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for t := range s.accessTokens {
+		delete(s.accessTokens, t)
+	}
+	for t := range s.controlTokens {
+		delete(s.controlTokens, t)
+	}
+}
+
+// issueToken adds a new token to the valid ones. s.mu must be held.
+func (s *Server) issueToken(prefix string, valid map[string]bool) string {
+	s.tokens++
+	token := fmt.Sprintf("%s%d", prefix, s.tokens)
+	valid[token] = true
+	return token
+}
+
+// vehicle returns the vehicle with the given id. s.mu must be held.
+func (s *Server) vehicle(id string) *Vehicle {
+	for _, v := range s.vehicles {
+		if v.ID == id {
+			return v
+		}
+	}
+	return nil
+}
+
+// nextMsgID returns a new message id. s.mu must be held.
+func (s *Server) nextMsgID() string {
+	s.msgID++
+	return fmt.Sprintf("00000000-0000-0000-0000-%012d", s.msgID)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := strings.Trim(r.URL.Path, "/")
+	parts := strings.Split(path, "/")
+
+	var endpoint Endpoint
+	var handler func(http.ResponseWriter, *http.Request, []string)
+	switch {
+	case path == "api/v1/spa/notifications/register" && r.Method == http.MethodPost:
+		endpoint, handler = EndpointDeviceID, s.handleDeviceID
+	case path == "api/v1/user/oauth2/authorize" && r.Method == http.MethodGet:
+		endpoint, handler = EndpointAuthorize, s.handleAuthorize
+	case path == "api/v1/user/language" && r.Method == http.MethodPost:
+		endpoint, handler = EndpointLanguage, s.handleLanguage
+	case path == "api/v1/user/signin" && r.Method == http.MethodPost:
+		endpoint, handler = EndpointSignIn, s.handleSignIn
+	case path == "api/v1/user/oauth2/token" && r.Method == http.MethodPost:
+		endpoint, handler = EndpointToken, s.handleToken
+	case path == "api/v1/user/pin" && r.Method == http.MethodPut:
+		endpoint, handler = EndpointPin, s.handlePin
+	case path == "api/v1/spa/vehicles" && r.Method == http.MethodGet:
+		endpoint, handler = EndpointVehicles, s.authorized(s.accessTokens, s.handleVehicles)
+	case len(parts) == 6 && strings.HasPrefix(path, "api/v1/spa/vehicles/") &&
+		parts[5] == "status" && r.Method == http.MethodGet:
+		endpoint, handler = EndpointStatus, s.authorized(s.accessTokens, s.handleStatus)
+	case len(parts) == 7 && strings.HasPrefix(path, "api/v1/spa/vehicles/") &&
+		strings.HasSuffix(path, "/status/latest") && r.Method == http.MethodGet:
+		endpoint, handler = EndpointStatus, s.authorized(s.accessTokens, s.handleLatestStatus)
+	case len(parts) == 8 && strings.HasPrefix(path, "api/v1/spa/vehicles/") &&
+		strings.HasSuffix(path, "/ccs2/carstatus/latest") && r.Method == http.MethodGet:
+		endpoint, handler = EndpointStatus, s.authorized(s.accessTokens, s.handleCCS2Status)
+	case len(parts) == 7 && strings.HasPrefix(path, "api/v1/spa/vehicles/") &&
+		strings.HasSuffix(path, "/location/park") && r.Method == http.MethodGet:
+		endpoint, handler = EndpointLocation, s.authorized(s.accessTokens, s.handleLocation)
+	case len(parts) == 7 && strings.HasPrefix(path, "api/v2/spa/vehicles/") &&
+		parts[5] == "control" && r.Method == http.MethodPost:
+		endpoint, handler = EndpointControl, s.authorized(s.controlTokens, s.handleControl)
+	case len(parts) == 8 && strings.HasPrefix(path, "api/v2/spa/vehicles/") &&
+		parts[5] == "ccs2" && parts[6] == "control" && r.Method == http.MethodPost:
+		endpoint, handler = EndpointControl, s.authorized(s.controlTokens, s.handleControl)
+	case len(parts) == 6 && strings.HasPrefix(path, "api/v1/spa/notifications/") &&
+		parts[5] == "records" && r.Method == http.MethodGet:
+		endpoint, handler = EndpointRecords, s.authorized(s.accessTokens, s.handleRecords)
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	if f := s.failures[endpoint]; len(f) > 0 {
+		s.failures[endpoint] = f[1:]
+		s.writeFailure(w, f[0])
+		return
+	}
+	handler(w, r, parts)
+}
+
+// authorized rejects requests not carrying one of the given bearer tokens.
+func (s *Server) authorized(tokens map[string]bool, next func(http.ResponseWriter, *http.Request, []string)) func(http.ResponseWriter, *http.Request, []string) {
+	return func(w http.ResponseWriter, r *http.Request, parts []string) {
+		if !tokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")] {
+			s.writeFailure(w, Failure{StatusCode: http.StatusUnauthorized, ResCode: "4001", Message: "Invalid token"})
+			return
+		}
+		next(w, r, parts)
+	}
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(v)
+}
+
+func (s *Server) writeMessage(w http.ResponseWriter, msg interface{}) {
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"retCode": "S",
+		"resCode": resCodeOk,
+		"resMsg":  msg,
+		"msgId":   s.nextMsgID(),
+	})
+}
+
+func (s *Server) writeFailure(w http.ResponseWriter, f Failure) {
+	statusCode := f.StatusCode
+	if statusCode == 0 {
+		statusCode = http.StatusBadRequest
+	}
+	if f.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(f.RetryAfter))
+	}
+	s.writeJSON(w, statusCode, map[string]interface{}{
+		"retCode": "F",
+		"resCode": f.ResCode,
+		"resMsg":  f.Message,
+		"msgId":   s.nextMsgID(),
+	})
+}
+
+func (s *Server) handleDeviceID(w http.ResponseWriter, r *http.Request, _ []string) {
+	s.writeMessage(w, map[string]interface{}{"deviceId": deviceID})
+}
+
+func (s *Server) handleAuthorize(w http.ResponseWriter, r *http.Request, _ []string) {
+	http.SetCookie(w, &http.Cookie{Name: "account", Value: "test", Path: "/"})
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) handleLanguage(w http.ResponseWriter, r *http.Request, _ []string) {
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) handleSignIn(w http.ResponseWriter, r *http.Request, _ []string) {
+	var req struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil ||
+		req.Email != s.credentials.Username || req.Password != s.credentials.Password {
+		s.writeJSON(w, http.StatusBadRequest, map[string]interface{}{"errId": "invalid credentials"})
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"redirectUrl": s.URL + "/api/v1/user/oauth2/redirect?code=" + authCode + "&state=test",
+	})
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request, _ []string) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("code") != authCode {
+		s.writeJSON(w, http.StatusBadRequest, map[string]interface{}{"error": "invalid_grant"})
+		return
+	}
+	token := s.issueToken(accessTokenPrefix, s.accessTokens)
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"token_type":   "Bearer",
+		"access_token": token,
+		"expires_in":   86400,
+	})
+}
+
+func (s *Server) handlePin(w http.ResponseWriter, r *http.Request, _ []string) {
+	var req struct {
+		DeviceID string `json:"deviceId"`
+		Pin      string `json:"pin"`
+	}
+	if !s.accessTokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")] {
+		s.writeFailure(w, Failure{StatusCode: http.StatusUnauthorized, ResCode: "4001", Message: "Invalid token"})
+		return
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Pin != s.credentials.PIN {
+		s.writeJSON(w, http.StatusBadRequest, map[string]interface{}{"errCode": "4003", "errMsg": "invalid pin"})
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"controlToken": s.issueToken(controlTokenPrefix, s.controlTokens),
+		"expiresTime":  600,
+	})
+}
+
+func (s *Server) handleVehicles(w http.ResponseWriter, r *http.Request, _ []string) {
+	vehicles := []interface{}{}
+	for _, v := range s.vehicles {
+		ccs2 := 0
+		if v.CCS2 {
+			ccs2 = 1
+		}
+		vehicles = append(vehicles, map[string]interface{}{
+			"vehicleId":              v.ID,
+			"vin":                    v.VIN,
+			"vehicleName":            v.Name,
+			"type":                   v.Type,
+			"nickname":               v.Name,
+			"master":                 true,
+			"carShare":               1,
+			"regDate":                "2021-01-01 12:00:00.000",
+			"ccuCCS2ProtocolSupport": ccs2,
+			"featureList":            v.Features,
+			"detailInfo":             map[string]interface{}{},
+		})
+	}
+	s.writeMessage(w, map[string]interface{}{"vehicles": vehicles})
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request, parts []string) {
+	v := s.vehicle(parts[4])
+	if v == nil {
+		s.writeFailure(w, Failure{StatusCode: http.StatusBadRequest, ResCode: "4004", Message: "Invalid vehicle"})
+		return
+	}
+	st := v.State
+	s.writeMessage(w, map[string]interface{}{
+		"doorLock": st.Locked,
+		"windowOpen": map[string]interface{}{
+			"frontLeft":  flag(st.Windows.FrontLeft),
+			"frontRight": flag(st.Windows.FrontRight),
+			"backLeft":   flag(st.Windows.BackLeft),
+			"backRight":  flag(st.Windows.BackRight),
+		},
+		"sunroofOpen": st.Windows.Sunroof,
+		"evStatus": map[string]interface{}{
+			"batteryCharge": st.Charging,
+			"batteryStatus": st.SoC,
+			"batteryPlugin": flag(st.PluggedIn),
+			"drvDistance": []interface{}{map[string]interface{}{
+				"rangeByFuel": map[string]interface{}{
+					"evModeRange": map[string]interface{}{"value": st.Range, "unit": 1},
+				},
+			}},
+			"reservChargeInfos": map[string]interface{}{
+				"targetSOClist": []interface{}{
+					map[string]interface{}{"targetSOClevel": st.TargetSoCAC, "plugType": int(goblue.PlugTypeAC)},
+					map[string]interface{}{"targetSOClevel": st.TargetSoCDC, "plugType": int(goblue.PlugTypeDC)},
+				},
+			},
+		},
+		"time": st.UpdatedAt.In(apiLocation).Format(timeLayout),
+	})
+}
+
+func (s *Server) handleLatestStatus(w http.ResponseWriter, r *http.Request, parts []string) {
+	v := s.vehicle(parts[4])
+	if v == nil {
+		s.writeFailure(w, Failure{StatusCode: http.StatusBadRequest, ResCode: "4004", Message: "Invalid vehicle"})
+		return
+	}
+	s.writeMessage(w, map[string]interface{}{
+		"vehicleStatusInfo": map[string]interface{}{
+			"odometer": map[string]interface{}{"value": v.State.Odometer, "unit": 1},
+		},
+	})
+}
+
+func (s *Server) handleLocation(w http.ResponseWriter, r *http.Request, parts []string) {
+	v := s.vehicle(parts[4])
+	if v == nil {
+		s.writeFailure(w, Failure{StatusCode: http.StatusBadRequest, ResCode: "4004", Message: "Invalid vehicle"})
+		return
+	}
+	loc := v.State.Location
+	s.writeMessage(w, map[string]interface{}{
+		"gpsDetail": map[string]interface{}{
+			"coord": map[string]interface{}{"lat": loc.Latitude, "lon": loc.Longitude, "alt": loc.Altitude, "type": 0},
+			"head":  loc.Heading,
+			"speed": map[string]interface{}{"value": 0, "unit": 0},
+			"time":  loc.UpdatedAt.In(apiLocation).Format(timeLayout),
+		},
+	})
+}
+
+func (s *Server) handleCCS2Status(w http.ResponseWriter, r *http.Request, parts []string) {
+	v := s.vehicle(parts[4])
+	if v == nil || !v.CCS2 {
+		s.writeFailure(w, Failure{StatusCode: http.StatusBadRequest, ResCode: "4004", Message: "Invalid vehicle"})
+		return
+	}
+	st := v.State
+	remaining := 0
+	if st.Charging {
+		remaining = 60
+	}
+	lock := flag(!st.Locked)
+	door := map[string]interface{}{"Lock": lock, "Open": 0}
+	s.writeMessage(w, map[string]interface{}{
+		"lastUpdateTime": st.UpdatedAt.In(apiLocation).Format(timeLayout),
+		"state": map[string]interface{}{
+			"Vehicle": map[string]interface{}{
+				"Green": map[string]interface{}{
+					"BatteryManagement": map[string]interface{}{
+						"BatteryRemain": map[string]interface{}{"Ratio": st.SoC},
+					},
+					"ChargingInformation": map[string]interface{}{
+						"ConnectorFastening": map[string]interface{}{"State": flag(st.PluggedIn)},
+						"Charging":           map[string]interface{}{"RemainTime": remaining},
+						"TargetSoC":          map[string]interface{}{"Standard": st.TargetSoCAC, "Quick": st.TargetSoCDC},
+					},
+				},
+				"Drivetrain": map[string]interface{}{
+					"Odometer": st.Odometer,
+					"FuelSystem": map[string]interface{}{
+						"DTE": map[string]interface{}{"Total": st.Range},
+					},
+				},
+				"Cabin": map[string]interface{}{
+					"Door": map[string]interface{}{
+						"Row1": map[string]interface{}{"Driver": door, "Passenger": door},
+						"Row2": map[string]interface{}{"Left": door, "Right": door},
+					},
+					"Window": map[string]interface{}{
+						"Row1": map[string]interface{}{
+							"Driver":    map[string]interface{}{"Open": flag(st.Windows.FrontLeft)},
+							"Passenger": map[string]interface{}{"Open": flag(st.Windows.FrontRight)},
+						},
+						"Row2": map[string]interface{}{
+							"Left":  map[string]interface{}{"Open": flag(st.Windows.BackLeft)},
+							"Right": map[string]interface{}{"Open": flag(st.Windows.BackRight)},
+						},
+					},
+				},
+				"Body": map[string]interface{}{
+					"Sunroof": map[string]interface{}{
+						"Glass": map[string]interface{}{"Open": flag(st.Windows.Sunroof)},
+					},
+				},
+			},
+		},
+	})
+}
+
+func (s *Server) handleControl(w http.ResponseWriter, r *http.Request, parts []string) {
+	v := s.vehicle(parts[4])
+	if v == nil {
+		s.writeFailure(w, Failure{StatusCode: http.StatusBadRequest, ResCode: "4004", Message: "Invalid vehicle"})
+		return
+	}
+
+	payload := map[string]interface{}{}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		s.writeFailure(w, Failure{StatusCode: http.StatusBadRequest, ResCode: "4000", Message: err.Error()})
+		return
+	}
+	name := parts[len(parts)-1]
+	s.commands = append(s.commands, Command{VehicleID: v.ID, Name: name, Payload: payload})
+
+	if s.commandResult == "success" {
+		s.apply(v, name, payload)
+	}
+
+	msgID := s.nextMsgID()
+	s.records[v.ID] = append(s.records[v.ID], map[string]interface{}{
+		"recordId": msgID,
+		"result":   s.commandResult,
+	})
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"retCode": "S",
+		"resCode": resCodeOk,
+		"msgId":   msgID,
+	})
+}
+
+// apply changes the vehicle state according to a successful command.
+func (s *Server) apply(v *Vehicle, name string, payload map[string]interface{}) {
+	// classic commands carry an action, ccs2 commands a command
+	action, ok := payload["command"]
+	if !ok {
+		action = payload["action"]
+	}
+	switch name {
+	case "door":
+		v.State.Locked = action == "close"
+	case "temperature":
+		v.State.ClimateOn = action == "start"
+	case "charge":
+		v.State.Charging = action == "start" && v.State.PluggedIn
+	case "window":
+		open := action == "vent"
+		v.State.Windows = goblue.Windows{
+			FrontLeft:  open,
+			FrontRight: open,
+			BackLeft:   open,
+			BackRight:  open,
+		}
+	}
+	v.State.UpdatedAt = time.Now()
+}
+
+func (s *Server) handleRecords(w http.ResponseWriter, r *http.Request, parts []string) {
+	records := s.records[parts[4]]
+	if records == nil {
+		records = []map[string]interface{}{}
+	}
+	s.writeMessage(w, records)
+}
+
+func flag(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
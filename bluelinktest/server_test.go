@@ -0,0 +1,181 @@
+package bluelinktest_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/frzifus/goblue"
+	"github.com/frzifus/goblue/bluelinktest"
+)
+
+var testCredentials = bluelinktest.Credentials{Username: "user", Password: "secret", PIN: "1234"}
+
+// newClient starts a server with one electric vehicle and returns a client
+// authenticated against it.
+func newClient(t *testing.T) (*bluelinktest.Server, *goblue.Client) {
+	t.Helper()
+	srv := bluelinktest.NewServer(testCredentials)
+	t.Cleanup(srv.Close)
+	srv.AddVehicle(bluelinktest.Vehicle{
+		ID:   "00000000-0000-0000-0000-0000000000a1",
+		VIN:  "KMHTEST0000000001",
+		Name: "Kona",
+		Type: "EV",
+		State: bluelinktest.State{
+			Locked:      true,
+			PluggedIn:   true,
+			SoC:         80,
+			Range:       320,
+			TargetSoCAC: 90,
+			TargetSoCDC: 80,
+			UpdatedAt:   time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		},
+	})
+
+	c, err := goblue.NewClient(srv.Config(goblue.BrandHyundai), srv.ClientOptions()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Authenticate(); err != nil {
+		t.Fatal(err)
+	}
+	return srv, c
+}
+
+func vehicle(t *testing.T, c *goblue.Client) *goblue.Vehicle {
+	t.Helper()
+	vs, err := c.Vehicles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vs) != 1 {
+		t.Fatalf("got %d vehicles, want 1", len(vs))
+	}
+	return vs[0]
+}
+
+func TestAuthenticate(t *testing.T) {
+	srv := bluelinktest.NewServer(testCredentials)
+	defer srv.Close()
+
+	cfg := srv.Config(goblue.BrandKia)
+	c, err := goblue.NewClient(cfg, srv.ClientOptions()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Vehicles(); !errors.Is(err, goblue.ErrNotAuthenticated) {
+		t.Errorf("Vehicles before Authenticate: got %v, want ErrNotAuthenticated", err)
+	}
+	if err := c.Authenticate(); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.Password = "wrong"
+	c, err = goblue.NewClient(cfg, srv.ClientOptions()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Authenticate(); !errors.Is(err, goblue.ErrAuthenticationFailed) {
+		t.Errorf("wrong password: got %v, want ErrAuthenticationFailed", err)
+	}
+}
+
+func TestVehicles(t *testing.T) {
+	_, c := newClient(t)
+	v := vehicle(t, c)
+
+	if v.ID() != "00000000-0000-0000-0000-0000000000a1" || v.VIN() != "KMHTEST0000000001" ||
+		v.Name() != "Kona" || v.Type() != "EV" || v.Brand() != goblue.BrandHyundai {
+		t.Errorf("unexpected vehicle %s %s %s %s %s", v.ID(), v.VIN(), v.Name(), v.Type(), v.Brand())
+	}
+	if !v.Capabilities().Has(goblue.CapabilityEV) {
+		t.Errorf("capabilities %s lack EV", v.Capabilities())
+	}
+	if got := v.Info().RegDate; got.IsZero() {
+		t.Error("registration date not decoded")
+	}
+}
+
+func TestStatus(t *testing.T) {
+	_, c := newClient(t)
+	st, err := vehicle(t, c).Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !st.DoorIsLocked() || st.IsCharging() || !st.PluggedIn() {
+		t.Errorf("locked %v, charging %v, plugged in %v", st.DoorIsLocked(), st.IsCharging(), st.PluggedIn())
+	}
+	if st.SoC() != 80 || st.RangeLeft() != 320 || st.TargetSocAC() != 90 || st.TargetSocDC() != 80 {
+		t.Errorf("soc %d, range %d, targets %d/%d", st.SoC(), st.RangeLeft(), st.TargetSocAC(), st.TargetSocDC())
+	}
+	if want := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC); !st.UpdatedAt().Equal(want) {
+		t.Errorf("updated at %v, want %v", st.UpdatedAt(), want)
+	}
+}
+
+func TestCommand(t *testing.T) {
+	srv, c := newClient(t)
+	v := vehicle(t, c)
+
+	if err := v.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+	cmds := srv.Commands()
+	if len(cmds) != 1 || cmds[0].VehicleID != v.ID() || cmds[0].Name != "door" || cmds[0].Payload["action"] != "open" {
+		t.Fatalf("got commands %+v", cmds)
+	}
+	if st, _ := srv.State(v.ID()); st.Locked {
+		t.Error("vehicle still locked")
+	}
+
+	srv.SetCommandResult("fail")
+	if err := v.Lock(); !errors.Is(err, goblue.ErrCommandFailed) {
+		t.Errorf("failed command: got %v, want ErrCommandFailed", err)
+	}
+}
+
+func TestInjectedFailure(t *testing.T) {
+	srv, c := newClient(t)
+	v := vehicle(t, c)
+
+	srv.Fail(bluelinktest.EndpointStatus, bluelinktest.FailureRateLimited, 1)
+	_, err := v.Status()
+	if !errors.Is(err, goblue.ErrRateLimited) {
+		t.Fatalf("got %v, want ErrRateLimited", err)
+	}
+	var apiErr *goblue.APIError
+	if !errors.As(err, &apiErr) || apiErr.ResCode != "5091" {
+		t.Errorf("got %#v, want api error with resCode 5091", err)
+	}
+
+	if _, err := v.Status(); err != nil {
+		t.Errorf("status after the injected failure: %v", err)
+	}
+}
+
+func TestUnsupportedCommand(t *testing.T) {
+	srv := bluelinktest.NewServer(testCredentials)
+	defer srv.Close()
+	srv.AddVehicle(bluelinktest.Vehicle{ID: "00000000-0000-0000-0000-0000000000b1", Type: "GN", Features: []string{"REMOTE_WINDOW"}})
+
+	c, err := goblue.NewClient(srv.Config(goblue.BrandKia), srv.ClientOptions()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Authenticate(); err != nil {
+		t.Fatal(err)
+	}
+	v := vehicle(t, c)
+
+	if err := v.HornAndLights(); !errors.Is(err, goblue.ErrUnsupported) {
+		t.Errorf("horn of a classic vehicle: got %v, want ErrUnsupported", err)
+	}
+	if err := v.StartCharge(); !errors.Is(err, goblue.ErrUnsupported) {
+		t.Errorf("charge of a combustion vehicle: got %v, want ErrUnsupported", err)
+	}
+	if cmds := srv.Commands(); len(cmds) != 0 {
+		t.Errorf("unsupported commands reached the api: %+v", cmds)
+	}
+}
@@ -0,0 +1,120 @@
+package goblue
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Capability is a single feature a vehicle may support.
+type Capability uint
+
+const (
+	CapabilityEV Capability = 1 << iota
+	CapabilityPHEV
+	CapabilityHEV
+	CapabilityICE
+	CapabilityClimateSeats
+	CapabilityHeatedSteeringWheel
+	CapabilityWindowControl
+	CapabilityChargeLimits
+	CapabilityCCS2
+	CapabilityCharging
+)
+
+var capabilityNames = []struct {
+	c    Capability
+	name string
+}{
+	{CapabilityEV, "ev"},
+	{CapabilityPHEV, "phev"},
+	{CapabilityHEV, "hev"},
+	{CapabilityICE, "ice"},
+	{CapabilityClimateSeats, "climate-seats"},
+	{CapabilityHeatedSteeringWheel, "heated-steering-wheel"},
+	{CapabilityWindowControl, "window-control"},
+	{CapabilityChargeLimits, "charge-limits"},
+	{CapabilityCCS2, "ccs2"},
+	{CapabilityCharging, "charging"},
+}
+
+// Capabilities is the set of features supported by a vehicle.
+type Capabilities Capability
+
+// Has reports whether all given capabilities are part of the set.
+func (c Capabilities) Has(caps ...Capability) bool {
+	for _, want := range caps {
+		if Capability(c)&want == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (c Capabilities) String() string {
+	var names []string
+	for _, n := range capabilityNames {
+		if c.Has(n.c) {
+			names = append(names, n.name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// vehicle types as reported by the vehicles endpoint
+const (
+	vehicleTypeEV   = "EV"
+	vehicleTypePHEV = "PHEV"
+	vehicleTypePE   = "PE"
+	vehicleTypeHEV  = "HV"
+	vehicleTypeICE  = "GN"
+)
+
+// featureCapabilities maps the entries of the feature list reported by the
+// vehicles endpoint to capabilities. The sample response in
+// testdata/vehicles lists each of them.
+var featureCapabilities = map[string]Capability{
+	"SEAT_CLIMATE":        CapabilityClimateSeats,
+	"STEERING_WHEEL_HEAT": CapabilityHeatedSteeringWheel,
+	"REMOTE_WINDOW":       CapabilityWindowControl,
+	"CHARGE_LIMIT":        CapabilityChargeLimits,
+}
+
+// detectCapabilities derives the capabilities of a vehicle from its type,
+// the protocol it speaks and its feature list. Unknown features are
+// ignored.
+func detectCapabilities(vtype string, ccs2 bool, features []string) Capabilities {
+	var c Capability
+	switch vtype {
+	case vehicleTypeEV:
+		c |= CapabilityEV | CapabilityCharging | CapabilityChargeLimits
+	case vehicleTypePHEV, vehicleTypePE:
+		c |= CapabilityPHEV | CapabilityCharging | CapabilityChargeLimits
+	case vehicleTypeHEV:
+		c |= CapabilityHEV
+	case vehicleTypeICE:
+		c |= CapabilityICE
+	}
+	if ccs2 {
+		c |= CapabilityCCS2 | CapabilityWindowControl
+	}
+	for _, f := range features {
+		c |= featureCapabilities[strings.ToUpper(f)]
+	}
+	return Capabilities(c)
+}
+
+// require fails with ErrUnsupported naming the missing capabilities unless
+// the vehicle supports all given capabilities.
+func (v *Vehicle) require(caps ...Capability) error {
+	have := v.Capabilities()
+	var missing Capability
+	for _, c := range caps {
+		if !have.Has(c) {
+			missing |= c
+		}
+	}
+	if missing != 0 {
+		return fmt.Errorf("%w: requires %s", ErrUnsupported, Capabilities(missing))
+	}
+	return nil
+}
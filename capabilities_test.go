@@ -0,0 +1,28 @@
+package goblue
+
+import "testing"
+
+func TestDetectCapabilities(t *testing.T) {
+	tests := []struct {
+		vtype    string
+		ccs2     bool
+		features []string
+		want     Capabilities
+	}{
+		{"EV", false, nil, Capabilities(CapabilityEV | CapabilityCharging | CapabilityChargeLimits)},
+		{"PE", false, nil, Capabilities(CapabilityPHEV | CapabilityCharging | CapabilityChargeLimits)},
+		{"HV", false, []string{}, Capabilities(CapabilityHEV)},
+		{"GN", false, []string{"REMOTE_WINDOW", "seat_climate"},
+			Capabilities(CapabilityICE | CapabilityWindowControl | CapabilityClimateSeats)},
+		{"EV", true, []string{"STEERING_WHEEL_HEAT", "UNKNOWN"},
+			Capabilities(CapabilityEV | CapabilityCharging | CapabilityChargeLimits | CapabilityCCS2 | CapabilityWindowControl |
+				CapabilityHeatedSteeringWheel)},
+		{"HV", false, []string{"CHARGE_LIMIT"}, Capabilities(CapabilityHEV | CapabilityChargeLimits)},
+	}
+
+	for _, tt := range tests {
+		if got := detectCapabilities(tt.vtype, tt.ccs2, tt.features); got != tt.want {
+			t.Errorf("detectCapabilities(%q, %v, %v) = %s, want %s", tt.vtype, tt.ccs2, tt.features, got, tt.want)
+		}
+	}
+}
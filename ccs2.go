@@ -0,0 +1,151 @@
+package goblue
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+)
+
+const ccs2TimeLayout = "20060102150405"
+
+type ccs2Door struct {
+	Lock int `json:"Lock"`
+	Open int `json:"Open"`
+}
+
+type ccs2Window struct {
+	Open int `json:"Open"`
+}
+
+// ccs2StatusResponse is the resMsg of the ccs2 status endpoint. Only the
+// fields mapped to VehicleStatus are decoded.
+type ccs2StatusResponse struct {
+	Lastupdatetime string `json:"lastUpdateTime"`
+	State          struct {
+		Vehicle struct {
+			Green struct {
+				Batterymanagement struct {
+					Batteryremain struct {
+						Ratio float64 `json:"Ratio"`
+					} `json:"BatteryRemain"`
+				} `json:"BatteryManagement"`
+				Charginginformation struct {
+					Connectorfastening struct {
+						State int `json:"State"`
+					} `json:"ConnectorFastening"`
+					Charging struct {
+						Remaintime int `json:"RemainTime"`
+					} `json:"Charging"`
+					Targetsoc struct {
+						Standard int `json:"Standard"`
+						Quick    int `json:"Quick"`
+					} `json:"TargetSoC"`
+				} `json:"ChargingInformation"`
+			} `json:"Green"`
+			Drivetrain struct {
+				Fuelsystem struct {
+					Dte struct {
+						Total float64 `json:"Total"`
+					} `json:"DTE"`
+				} `json:"FuelSystem"`
+			} `json:"Drivetrain"`
+			Cabin struct {
+				Door struct {
+					Row1 struct {
+						Driver    ccs2Door `json:"Driver"`
+						Passenger ccs2Door `json:"Passenger"`
+					} `json:"Row1"`
+					Row2 struct {
+						Left  ccs2Door `json:"Left"`
+						Right ccs2Door `json:"Right"`
+					} `json:"Row2"`
+				} `json:"Door"`
+				Window struct {
+					Row1 struct {
+						Driver    ccs2Window `json:"Driver"`
+						Passenger ccs2Window `json:"Passenger"`
+					} `json:"Row1"`
+					Row2 struct {
+						Left  ccs2Window `json:"Left"`
+						Right ccs2Window `json:"Right"`
+					} `json:"Row2"`
+				} `json:"Window"`
+				Seat *struct {
+					Row1 struct {
+						Driver struct {
+							Climate struct {
+								State int `json:"State"`
+							} `json:"Climate"`
+						} `json:"Driver"`
+					} `json:"Row1"`
+				} `json:"Seat"`
+				Steeringwheel *struct {
+					Heat struct {
+						State int `json:"State"`
+					} `json:"Heat"`
+				} `json:"SteeringWheel"`
+			} `json:"Cabin"`
+			Body struct {
+				Sunroof struct {
+					Glass ccs2Window `json:"Glass"`
+				} `json:"Sunroof"`
+			} `json:"Body"`
+		} `json:"Vehicle"`
+	} `json:"state"`
+}
+
+// status maps the ccs2 layout onto the common VehicleStatus model.
+func (m *ccs2StatusResponse) status() (*VehicleStatus, error) {
+	updatedAt := time.Now()
+	if m.Lastupdatetime != "" {
+		var err error
+		if updatedAt, err = time.ParseInLocation(ccs2TimeLayout, m.Lastupdatetime, apiLocation); err != nil {
+			return nil, err
+		}
+	}
+
+	vs := m.State.Vehicle
+	doors, windows := vs.Cabin.Door, vs.Cabin.Window
+	charging := vs.Green.Charginginformation
+	plugged := charging.Connectorfastening.State
+
+	return &VehicleStatus{
+		updatedAt: updatedAt,
+		// a set lock flag marks an unlocked door
+		doorIsLocked: doors.Row1.Driver.Lock == 0 && doors.Row1.Passenger.Lock == 0 &&
+			doors.Row2.Left.Lock == 0 && doors.Row2.Right.Lock == 0,
+		isCharging:  plugged != 0 && charging.Charging.Remaintime > 0,
+		batterySoc:  int(math.Round(vs.Green.Batterymanagement.Batteryremain.Ratio)),
+		plugState:   plugged,
+		rangeLeft:   int(math.Round(vs.Drivetrain.Fuelsystem.Dte.Total)),
+		targetSocAC: charging.Targetsoc.Standard,
+		targetSocDC: charging.Targetsoc.Quick,
+		windows: Windows{
+			FrontLeft:  windows.Row1.Driver.Open != 0,
+			FrontRight: windows.Row1.Passenger.Open != 0,
+			BackLeft:   windows.Row2.Left.Open != 0,
+			BackRight:  windows.Row2.Right.Open != 0,
+			Sunroof:    vs.Body.Sunroof.Glass.Open != 0,
+		},
+	}, nil
+}
+
+// ccs2Status requests the status of vehicles speaking the ccs2 protocol.
+func (v *Vehicle) ccs2Status(ctx context.Context) (*VehicleStatus, error) {
+	msg := ccs2StatusResponse{}
+	uri := fmt.Sprintf(v.auth.URI+v.endpoints.CCS2Status, v.id)
+	if err := v.doSpaRequest(ctx, http.MethodGet, uri, nil, &msg); err != nil {
+		return nil, err
+	}
+
+	if msg.State.Vehicle.Cabin.Seat != nil {
+		v.addCapabilities(CapabilityClimateSeats)
+	}
+	if msg.State.Vehicle.Cabin.Steeringwheel != nil {
+		v.addCapabilities(CapabilityHeatedSteeringWheel)
+	}
+
+	return msg.status()
+}
@@ -2,13 +2,15 @@ package goblue
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,19 +20,74 @@ import (
 const (
 	defaultUserAgent = "okhttp/3.10.0"
 
-	apiCodeOk   = "S"
-	apiCodeFail = "F"
+	apiCodeOk = "S"
+
+	regDateLayout = "2006-01-02 15:04:05"
 )
 
-// auth bundles miscellaneous authorization data
+// auth bundles miscellaneous authorization data. It is shared by a Client
+// and its vehicles, the fields are fixed once the client is created while
+// the session is replaced on each authentication.
 type auth struct {
 	URI               string
 	TokenAuth         string
 	CCSPServiceID     string
 	CCSPApplicationID string
-	CCSPDeviceID      string
-	AccessToken       string
 	UserAgent         string
+
+	mu   sync.RWMutex
+	sess session
+}
+
+// session is the result of an authentication.
+type session struct {
+	DeviceID    string
+	AccessToken string
+	Pin         string
+}
+
+func (a *auth) session() session {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.sess
+}
+
+func (a *auth) setSession(s session) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.sess = s
+}
+
+// cookieJar is a http.CookieJar that can be replaced while requests are in
+// flight.
+type cookieJar struct {
+	mu  sync.Mutex
+	jar http.CookieJar
+}
+
+func (j *cookieJar) current() http.CookieJar {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.jar
+}
+
+func (j *cookieJar) set(jar http.CookieJar) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.jar = jar
+}
+
+func (j *cookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	if jar := j.current(); jar != nil {
+		jar.SetCookies(u, cookies)
+	}
+}
+
+func (j *cookieJar) Cookies(u *url.URL) []*http.Cookie {
+	if jar := j.current(); jar != nil {
+		return jar.Cookies(u)
+	}
+	return nil
 }
 
 type ClientOptions func(*Client) error
@@ -49,16 +106,42 @@ func WithTimeout(d time.Duration) ClientOptions {
 	}
 }
 
+// WithBaseURL overrides the api url of the brand, e.g. to talk to a test
+// server.
+func WithBaseURL(uri string) ClientOptions {
+	return func(c *Client) error {
+		c.auth.URI = strings.TrimSuffix(uri, "/")
+		return nil
+	}
+}
+
+// WithDailyQuota limits the number of api calls per day. The policy decides
+// whether calls exceeding the budget fail with ErrRateLimited or wait for the
+// next day.
+func WithDailyQuota(limit int, policy QuotaPolicy) ClientOptions {
+	return func(c *Client) error {
+		c.quota.limit = limit
+		c.quota.policy = policy
+		return nil
+	}
+}
+
 func NewClient(cfg Config, opts ...ClientOptions) (*Client, error) {
+	jar := &cookieJar{}
 	cl := &Client{
 		http: &http.Client{
 			Timeout:   45 * time.Minute,
 			Transport: http.DefaultTransport,
+			Jar:       jar,
 		},
+		jar:       jar,
 		endpoints: defaultEndpoints(),
 		cfg:       cfg,
-		auth: auth{
+		quota:     newQuota(),
+		tracer:    noopTracer{},
+		auth: &auth{
 			UserAgent: defaultUserAgent,
+			sess:      session{Pin: cfg.Pin},
 		},
 	}
 
@@ -82,134 +165,195 @@ func NewClient(cfg Config, opts ...ClientOptions) (*Client, error) {
 			return nil, err
 		}
 	}
+	cl.http.Transport = &quotaTransport{quota: cl.quota, transport: cl.http.Transport}
+	if cl.retry != nil {
+		cl.http.Transport = &retryTransport{policy: cl.retry, transport: cl.http.Transport}
+	}
+	cl.api = chain(cl.http, append(cl.middleware, stampMiddleware(cfg.Brand))...)
 
 	return cl, nil
 }
 
+// Client is the entry point to the api of an account. It is safe for
+// concurrent use by multiple goroutines. Vehicles returned by a Client share
+// its authentication, calling Authenticate again renews it for all of them.
 type Client struct {
 	http      *http.Client
-	auth      auth
+	jar       *cookieJar
+	api       HttpClient
+	auth      *auth
+	authMu    sync.Mutex // serializes authentications
 	endpoints endpoints
 	cfg       Config
+	quota     *quota
+	retry     *RetryPolicy
+	metrics   MetricsRecorder
+	tracer    Tracer
+
+	middleware []Middleware
 }
 
-func (c *Client) Vehicles() ([]*Vehicle, error) {
-	if c.auth.AccessToken == "" {
-		return nil, ErrNotAuthenticated
-	}
+// Quota returns the api calls made today.
+func (c *Client) Quota() QuotaUsage {
+	return c.quota.usage()
+}
 
-	stamp, err := GetStampFromList(c.cfg.Brand)
-	if err != nil {
-		return nil, err
-	}
+func (c *Client) Vehicles() (_ []*Vehicle, err error) {
+	return c.VehiclesContext(context.Background())
+}
 
-	headers := map[string]string{
-		"Authorization":       c.auth.AccessToken,
-		"ccsp-device-id":      c.auth.CCSPDeviceID,
-		"ccsp-application-id": c.auth.CCSPApplicationID,
-		"offset":              "1",
-		"User-Agent":          c.auth.UserAgent,
-		"Stamp":               stamp,
-	}
+// VehiclesContext is like Vehicles, using ctx for the requests and the parent
+// span.
+func (c *Client) VehiclesContext(ctx context.Context) (_ []*Vehicle, err error) {
+	ctx, op := startOperation(ctx, c.tracer, "goblue.Client.Vehicles")
+	defer op.end(&err)
 
-	uri := c.auth.URI + c.endpoints.Vehicles
-	req, err := newHttpRequest(http.MethodGet, uri, nil, headers)
-	if err != nil {
-		return nil, err
+	sess := c.auth.session()
+	if sess.AccessToken == "" {
+		return nil, ErrNotAuthenticated
 	}
 
-	resp, err := c.http.Do(req)
+	uri := c.auth.URI + c.endpoints.Vehicles
+	req, err := newJSONRequest(ctx, http.MethodGet, uri, nil, c.auth.spaHeaders(sess, sess.AccessToken))
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
 	msg := struct {
-		Retcode string `json:"retCode"`
-		Rescode string `json:"resCode"`
-		Resmsg  struct {
-			Vehicles []struct {
-				ID         string `json:"vehicleId"`
-				Vin        string `json:"vin"`
-				Name       string `json:"vehicleName"`
-				Type       string `json:"type"`
-				Nickname   string `json:"nickname"`
-				Master     bool   `json:"master"`
-				Carshare   int    `json:"carShare"`
-				Regdate    string `json:"regDate"`
-				Detailinfo struct {
-					Salecarmdlcd   string `json:"saleCarmdlCd"`
-					Bodytype       string `json:"bodyType"`
-					Incolor        string `json:"inColor"`
-					Outcolor       string `json:"outColor"`
-					Salecarmdlennm string `json:"saleCarmdlEnNm"`
-				} `json:"detailInfo"`
-			} `json:"vehicles"`
-		} `json:"resMsg"`
-		Msgid string `json:"msgId"`
+		Vehicles []struct {
+			ID         string   `json:"vehicleId"`
+			Vin        string   `json:"vin"`
+			Name       string   `json:"vehicleName"`
+			Type       string   `json:"type"`
+			Nickname   string   `json:"nickname"`
+			Master     bool     `json:"master"`
+			Carshare   int      `json:"carShare"`
+			Regdate    string   `json:"regDate"`
+			CCS2       int      `json:"ccuCCS2ProtocolSupport"`
+			Features   []string `json:"featureList"`
+			Detailinfo struct {
+				Salecarmdlcd   string `json:"saleCarmdlCd"`
+				Bodytype       string `json:"bodyType"`
+				Incolor        string `json:"inColor"`
+				Outcolor       string `json:"outColor"`
+				Salecarmdlennm string `json:"saleCarmdlEnNm"`
+			} `json:"detailInfo"`
+		} `json:"vehicles"`
 	}{}
-
-	var buf bytes.Buffer
-	buf.ReadFrom(resp.Body)
-	if err := json.Unmarshal(buf.Bytes(), &msg); err != nil {
+	if _, err := doJSON(c.api, req, &msg); err != nil {
 		return nil, err
 	}
-	if msg.Retcode != apiCodeOk {
-		return nil, ErrNotAuthenticated
-	}
 
-	count := len(msg.Resmsg.Vehicles)
+	count := len(msg.Vehicles)
 	if count == 0 {
 		return nil, ErrNoVehicleFound
 	}
 
 	vehicles := make([]*Vehicle, count)
-	for i, v := range msg.Resmsg.Vehicles {
+	for i, v := range msg.Vehicles {
+		// the registration date is informational, an unknown format keeps
+		// the zero time instead of losing the vehicle
+		regDate, _ := time.ParseInLocation(regDateLayout, v.Regdate, apiLocation)
+		info := VehicleInfo{
+			Nickname:      v.Nickname,
+			Master:        v.Master,
+			CarShare:      v.Carshare,
+			RegDate:       regDate,
+			ModelCode:     v.Detailinfo.Salecarmdlcd,
+			ModelName:     v.Detailinfo.Salecarmdlennm,
+			BodyType:      v.Detailinfo.Bodytype,
+			InteriorColor: v.Detailinfo.Incolor,
+			ExteriorColor: v.Detailinfo.Outcolor,
+		}
+
 		vehicles[i] = NewVehicle(
 			v.ID, v.Vin, v.Name, v.Type,
 			c.cfg.Brand,
-			WithVehicleClient(c.http),
+			WithVehicleClient(c.api),
 			WithVehicleAuth(c.auth),
 			WithVehicleEndpoints(c.endpoints),
+			WithVehicleCapabilities(detectCapabilities(v.Type, v.CCS2 != 0, v.Features)),
+			WithVehicleInfo(info),
+			WithVehicleTracer(c.tracer),
 		)
 	}
 
 	return vehicles, nil
 }
 
-func (c *Client) Authenticate() error {
+func (c *Client) Authenticate() (err error) {
+	return c.AuthenticateContext(context.Background())
+}
+
+// AuthenticateContext is like Authenticate, using ctx for the requests and the
+// parent span.
+func (c *Client) AuthenticateContext(ctx context.Context) (err error) {
+	ctx, op := startOperation(ctx, c.tracer, "goblue.Client.Authenticate")
+	defer op.end(&err)
+
+	err = c.authenticate(ctx)
+	if c.metrics != nil {
+		c.metrics.RecordTokenRefresh(err)
+	}
+	return err
+}
+
+// Reauthenticate calls f and, if it fails with ErrNotAuthenticated,
+// authenticates the client using ctx and calls f again. Long running programs
+// wrap their calls with it to outlive expired sessions.
+func (c *Client) Reauthenticate(ctx context.Context, f func() error) error {
+	err := f()
+	if !errors.Is(err, ErrNotAuthenticated) {
+		return err
+	}
+	if err := c.AuthenticateContext(ctx); err != nil {
+		return err
+	}
+	return f()
+}
+
+// authenticate signs in and replaces the session once all steps succeeded,
+// requests running meanwhile keep using the previous session.
+func (c *Client) authenticate(ctx context.Context) error {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+
+	creds, err := c.cfg.ResolveCredentials()
+	if err != nil {
+		return fmt.Errorf("reading credentials: %w", err)
+	}
+
 	c.resetCookies()
 
-	deviceID, err := c.requestDeviceID()
+	deviceID, err := c.requestDeviceID(ctx)
 	if err != nil {
 		return err
 	}
-	c.auth.CCSPDeviceID = deviceID
 
-	if err := c.setCookiesAndVerify(); err != nil {
+	if err := c.setCookiesAndVerify(ctx); err != nil {
 		return err
 	}
 
 	const langEnglish = "en"
-	if err := c.setLanguage(langEnglish); err != nil {
+	if err := c.setLanguage(ctx, langEnglish); err != nil {
 		return err
 	}
 
 	var accCode string
-	if accCode, err = c.login(); err != nil {
+	if accCode, err = c.login(ctx, creds); err != nil {
 		return err
 	}
 
-	token, err := c.requestAccessToken(accCode)
+	token, err := c.requestAccessToken(ctx, accCode)
 	if err != nil {
 		return err
 	}
-	c.auth.AccessToken = token
+	c.auth.setSession(session{DeviceID: deviceID, AccessToken: token, Pin: creds.Pin})
 
 	return nil
 }
 
-func (c *Client) requestDeviceID() (string, error) {
+func (c *Client) requestDeviceID(ctx context.Context) (string, error) {
 	uniID, err := uuid.NewUUID()
 	if err != nil {
 		return "", err
@@ -221,71 +365,37 @@ func (c *Client) requestDeviceID() (string, error) {
 		"uuid":      uniID.String(),
 	}
 
-	stamp, err := GetStampFromList(c.cfg.Brand)
-	if err != nil {
-		return "", err
-	}
-
 	headers := map[string]string{
 		"ccsp-service-id": c.auth.CCSPServiceID,
-		"Content-type":    "application/json;charset=UTF-8",
 		"User-Agent":      c.auth.UserAgent,
-		"Stamp":           stamp,
-	}
-
-	body, err := json.Marshal(data)
-	if err != nil {
-		return "", err
 	}
 
 	uri := c.auth.URI + c.endpoints.DeviceID
-	req, err := newHttpRequest(http.MethodPost, uri, bytes.NewReader(body), headers)
-	if err != nil {
-		return "", err
-	}
-	r, err := c.http.Do(req)
+	req, err := newJSONRequest(ctx, http.MethodPost, uri, data, headers)
 	if err != nil {
 		return "", err
 	}
-	defer r.Body.Close()
 
-	if r.StatusCode != http.StatusOK {
-		return "", ErrNotAuthenticated
-	}
-
-	txt, err := io.ReadAll(r.Body)
-	if err != nil {
-		return "", err
-	}
-
-	resp := struct {
-		Retcode string `json:"retCode"`
-		Resmsg  struct {
-			Deviceid string `json:"deviceId"`
-		} `json:"resMsg"`
+	msg := struct {
+		Deviceid string `json:"deviceId"`
 	}{}
-	if err := json.Unmarshal(txt, &resp); err != nil {
+	if _, err := doJSON(c.api, req, &msg); err != nil {
 		return "", err
 	}
 
-	if resp.Retcode == apiCodeFail {
-		return "", ErrNotAuthenticated
-	}
-
-	return resp.Resmsg.Deviceid, nil
-
+	return msg.Deviceid, nil
 }
 
 func (c *Client) resetCookies() {
-	c.http.Jar = nil
+	c.jar.set(nil)
 }
 
-func (c *Client) setCookiesAndVerify() error {
+func (c *Client) setCookiesAndVerify(ctx context.Context) error {
 	jar, err := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
 	if err != nil {
 		return err
 	}
-	c.http.Jar = jar
+	c.jar.set(jar)
 
 	uri := fmt.Sprintf(
 		"%s/api/v1/user/oauth2/authorize?response_type=code&state=test&client_id=%s&redirect_uri=%s/api/v1/user/oauth2/redirect",
@@ -294,16 +404,21 @@ func (c *Client) setCookiesAndVerify() error {
 		c.auth.URI,
 	)
 
-	var resp *http.Response
-	if resp, err = c.http.Get(uri); err != nil {
+	req, err := newHttpRequest(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.api.Do(req)
+	if err != nil {
 		return err
 	}
 	resp.Body.Close()
 
-	return err
+	return nil
 }
 
-func (c *Client) setLanguage(lang string) error {
+func (c *Client) setLanguage(ctx context.Context, lang string) error {
 	data := map[string]interface{}{
 		"lang": lang,
 	}
@@ -314,12 +429,12 @@ func (c *Client) setLanguage(lang string) error {
 	}
 
 	uri := c.auth.URI + c.endpoints.Lang
-	req, err := newHttpRequest(http.MethodPost, uri, bytes.NewReader(body), JSONEncoding)
+	req, err := newHttpRequest(ctx, http.MethodPost, uri, bytes.NewReader(body), JSONEncoding)
 	if err != nil {
 		return err
 	}
 
-	resp, err := c.http.Do(req)
+	resp, err := c.api.Do(req)
 	if err != nil {
 		return err
 	}
@@ -327,10 +442,10 @@ func (c *Client) setLanguage(lang string) error {
 	return nil
 }
 
-func (c *Client) login() (string, error) {
+func (c *Client) login(ctx context.Context, creds Credentials) (string, error) {
 	data := map[string]interface{}{
-		"email":    c.cfg.Username,
-		"password": c.cfg.Password,
+		"email":    creds.Username,
+		"password": creds.Password,
 	}
 
 	body, err := json.Marshal(data)
@@ -339,7 +454,7 @@ func (c *Client) login() (string, error) {
 	}
 
 	uri := c.auth.URI + c.endpoints.Login
-	req, err := newHttpRequest(http.MethodPost, uri, bytes.NewReader(body), JSONEncoding)
+	req, err := newHttpRequest(ctx, http.MethodPost, uri, bytes.NewReader(body), JSONEncoding)
 	if err != nil {
 		return "", err
 	}
@@ -347,28 +462,22 @@ func (c *Client) login() (string, error) {
 	redirect := struct {
 		RedirectURL string `json:"redirectUrl"`
 	}{}
-
-	resp, err := c.http.Do(req)
-	if err != nil {
+	if err := doRawJSON(c.api, req, &redirect); err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest {
+			return "", ErrAuthenticationFailed
+		}
 		return "", err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusBadRequest { // TODO: verify good instead of bad
-		return "", ErrAuthenticationFailed
-	}
-
-	var buf bytes.Buffer
-	buf.ReadFrom(resp.Body)
-	if err := json.Unmarshal(buf.Bytes(), &redirect); err != nil {
+	parsed, err := url.Parse(redirect.RedirectURL)
+	if err != nil {
 		return "", err
 	}
-
-	parsed, err := url.Parse(redirect.RedirectURL)
-	return parsed.Query().Get("code"), err
+	return parsed.Query().Get("code"), nil
 }
 
-func (c *Client) requestAccessToken(accCode string) (string, error) {
+func (c *Client) requestAccessToken(ctx context.Context, accCode string) (string, error) {
 	headers := map[string]string{
 		"Authorization": "Basic " + c.auth.TokenAuth,
 		"Content-type":  "application/x-www-form-urlencoded",
@@ -382,7 +491,7 @@ func (c *Client) requestAccessToken(accCode string) (string, error) {
 	})
 
 	uri := c.auth.URI + c.endpoints.AccessToken
-	req, err := newHttpRequest(http.MethodPost, uri, strings.NewReader(data.Encode()), headers)
+	req, err := newHttpRequest(ctx, http.MethodPost, uri, strings.NewReader(data.Encode()), headers)
 	if err != nil {
 		return "", err
 	}
@@ -391,19 +500,11 @@ func (c *Client) requestAccessToken(accCode string) (string, error) {
 		TokenType   string `json:"token_type"`
 		AccessToken string `json:"access_token"`
 	}
-
-	resp, err := c.http.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-	var buf bytes.Buffer
-	buf.ReadFrom(resp.Body)
-	if err := json.Unmarshal(buf.Bytes(), &tokens); err != nil {
+	if err := doRawJSON(c.api, req, &tokens); err != nil {
 		return "", err
 	}
 
-	return fmt.Sprintf("%s %s", tokens.TokenType, tokens.AccessToken), err
+	return fmt.Sprintf("%s %s", tokens.TokenType, tokens.AccessToken), nil
 }
 
 // JSONEncoding specifies application/json
@@ -0,0 +1,82 @@
+package goblue
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestVehiclesKeepsVehicleWithInvalidRegDate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"retCode": "S", "resMsg": {"vehicles": [
+			{"vehicleId": "a", "vin": "VIN1", "type": "EV", "regDate": "not a date"},
+			{"vehicleId": "b", "vin": "VIN2", "type": "GN", "regDate": "2021-03-04 05:06:07.000"}
+		]}}`))
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(Config{Brand: BrandHyundai, Region: RegionEU}, WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.auth.setSession(session{AccessToken: "Bearer token"})
+
+	vs, err := c.Vehicles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vs) != 2 {
+		t.Fatalf("got %d vehicles, want 2", len(vs))
+	}
+	if got := vs[0].Info().RegDate; !got.IsZero() {
+		t.Errorf("invalid regDate decoded as %v", got)
+	}
+	if got, want := vs[1].Info().RegDate, time.Date(2021, 3, 4, 4, 6, 7, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("regDate %v, want %v", got, want)
+	}
+}
+
+func TestVehiclesCapabilities(t *testing.T) {
+	body, err := os.ReadFile(filepath.Join("testdata", "vehicles", "kia-eu.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(Config{Brand: BrandKia, Region: RegionEU}, WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.auth.setSession(session{AccessToken: "Bearer token"})
+
+	vs, err := c.Vehicles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Capabilities{
+		Capabilities(CapabilityEV | CapabilityCharging | CapabilityChargeLimits | CapabilityClimateSeats |
+			CapabilityHeatedSteeringWheel | CapabilityWindowControl),
+		Capabilities(CapabilityICE),
+	}
+	if len(vs) != len(want) {
+		t.Fatalf("got %d vehicles, want %d", len(vs), len(want))
+	}
+	for i, v := range vs {
+		if got := v.Capabilities(); got != want[i] {
+			t.Errorf("vehicle %d: got capabilities %s, want %s", i, got, want[i])
+		}
+	}
+	// every known feature is covered by the sample
+	for feature := range featureCapabilities {
+		if !bytes.Contains(body, []byte(`"`+feature+`"`)) {
+			t.Errorf("feature %s missing in the sample", feature)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+// Command goblue-corpus maintains the corpus of anonymized status responses
+// in testdata/status and the golden files holding their decoded VehicleStatus.
+//
+//	goblue-corpus add -name kia-eu-ev response.json   anonymize and add a sample
+//	goblue-corpus verify                              compare all samples with their golden files
+//	goblue-corpus update                              regenerate all golden files
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/frzifus/goblue"
+)
+
+const (
+	sampleSuffix = ".json"
+	goldenSuffix = ".golden.json"
+)
+
+func main() {
+	logger := log.New(os.Stderr, "", 0)
+
+	dir := flag.String("dir", filepath.Join("testdata", "status"), "corpus directory")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: goblue-corpus [-dir dir] add|verify|update [args]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	var err error
+	switch flag.Arg(0) {
+	case "add":
+		err = add(*dir, flag.Args()[1:])
+	case "verify":
+		err = verify(*dir)
+	case "update":
+		err = update(*dir)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		logger.Fatalln(err)
+	}
+}
+
+func add(dir string, args []string) error {
+	fs := flag.NewFlagSet("add", flag.ExitOnError)
+	name := fs.String("name", "", "sample name, <brand>-<region>-<type>, e.g. kia-eu-ev")
+	fs.Parse(args)
+	if *name == "" || fs.NArg() != 1 {
+		return fmt.Errorf("usage: goblue-corpus add -name <brand>-<region>-<type> <response.json>")
+	}
+
+	raw, err := ioutil.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if !json.Valid(raw) {
+		return fmt.Errorf("%s: invalid json", fs.Arg(0))
+	}
+	var sample bytes.Buffer
+	if err := json.Indent(&sample, goblue.NewAnonymizer().Anonymize(raw), "", "  "); err != nil {
+		return err
+	}
+	sample.WriteByte('\n')
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, *name+sampleSuffix)
+	if err := ioutil.WriteFile(path, sample.Bytes(), 0644); err != nil {
+		return err
+	}
+	return writeGolden(path)
+}
+
+func samples(dir string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*"+sampleSuffix))
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, path := range matches {
+		if !strings.HasSuffix(path, goldenSuffix) {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+func decode(path string) ([]byte, error) {
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	status, err := goblue.DecodeStatus(body)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	out, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+func goldenPath(path string) string {
+	return strings.TrimSuffix(path, sampleSuffix) + goldenSuffix
+}
+
+func writeGolden(path string) error {
+	out, err := decode(path)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(goldenPath(path), out, 0644)
+}
+
+func update(dir string) error {
+	paths, err := samples(dir)
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if err := writeGolden(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func verify(dir string) error {
+	paths, err := samples(dir)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no samples found in %s", dir)
+	}
+
+	var failed []string
+	for _, path := range paths {
+		got, err := decode(path)
+		if err != nil {
+			return err
+		}
+		want, err := ioutil.ReadFile(goldenPath(path))
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(got, want) {
+			failed = append(failed, filepath.Base(path))
+			fmt.Fprintf(os.Stderr, "%s: decoded status differs from golden file\ngot:\n%s\nwant:\n%s\n", path, got, want)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d samples failed: %s", len(failed), len(paths), strings.Join(failed, ", "))
+	}
+	fmt.Printf("%d samples ok\n", len(paths))
+	return nil
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/frzifus/goblue"
+)
+
+// environment variables overriding the config file
+const (
+	envConfig      = "GOBLUE_CONFIG"
+	envUsername    = "GOBLUE_USERNAME"
+	envPassword    = "GOBLUE_PASSWORD"
+	envPin         = "GOBLUE_PIN"
+	envBrand       = "GOBLUE_BRAND"
+	envRegion      = "GOBLUE_REGION"
+	envCredentials = "GOBLUE_CREDENTIALS"
+	envPassphrase  = "GOBLUE_PASSPHRASE"
+
+	envMQTTPassword = "GOBLUE_MQTT_PASSWORD"
+	envAPIKey       = "GOBLUE_API_KEY"
+)
+
+// config is the layout of the config file.
+type config struct {
+	goblue.Config
+	// CredentialSource names where the secrets of the account are read
+	// from, see credentialSource.
+	CredentialSource string `json:"credentials,omitempty"`
+}
+
+// defaultConfigPath returns the config file used if neither -config nor
+// GOBLUE_CONFIG are set.
+func defaultConfigPath() string {
+	if path := os.Getenv(envConfig); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "config.json"
+	}
+	return filepath.Join(dir, "goblue", "config.json")
+}
+
+// loadConfig reads the config file at path and applies the environment and
+// the given flags on top, later sources win. A missing file is only an error
+// if it was asked for explicitly.
+func loadConfig(path string, explicit bool, flags config) (config, error) {
+	cfg := config{Config: goblue.Config{Region: goblue.RegionEU}}
+
+	raw, err := ioutil.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(raw, &cfg); err != nil {
+			return cfg, err
+		}
+	case errors.Is(err, os.ErrNotExist) && !explicit:
+	default:
+		return cfg, err
+	}
+
+	merge(&cfg, config{
+		Config: goblue.Config{
+			Username: os.Getenv(envUsername),
+			Password: os.Getenv(envPassword),
+			Pin:      os.Getenv(envPin),
+			Brand:    goblue.Brand(os.Getenv(envBrand)),
+			Region:   goblue.Region(os.Getenv(envRegion)),
+		},
+		CredentialSource: os.Getenv(envCredentials),
+	})
+	merge(&cfg, flags)
+
+	if cfg.CredentialSource != "" {
+		src, err := credentialSource(cfg.CredentialSource)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.Credentials = src
+	} else if cfg.Username == "" || cfg.Password == "" {
+		return cfg, errors.New("username and password or a credential source are required, see -help")
+	}
+	return cfg, nil
+}
+
+// merge overwrites the fields of cfg set in o.
+func merge(cfg *config, o config) {
+	if o.Username != "" {
+		cfg.Username = o.Username
+	}
+	if o.Password != "" {
+		cfg.Password = o.Password
+	}
+	if o.Pin != "" {
+		cfg.Pin = o.Pin
+	}
+	if o.Brand != "" {
+		cfg.Brand = o.Brand
+	}
+	if o.Region != "" {
+		cfg.Region = o.Region
+	}
+	if o.CredentialSource != "" {
+		cfg.CredentialSource = o.CredentialSource
+	}
+}
+
+// credentialSource parses the description of a credential source:
+//
+//	env:PREFIX        PREFIX_USERNAME, PREFIX_PASSWORD and PREFIX_PIN
+//	command:CMD ARGS  a password manager command, e.g. command:pass show kia
+//	file:PATH         a file written by goblue credentials encrypt
+func credentialSource(spec string) (goblue.CredentialSource, error) {
+	kv := strings.SplitN(spec, ":", 2)
+	if len(kv) != 2 || kv[1] == "" {
+		return nil, fmt.Errorf("invalid credential source %q, use env:PREFIX, command:CMD or file:PATH", spec)
+	}
+
+	switch kv[0] {
+	case "env":
+		return goblue.EnvCredentials(kv[1]), nil
+	case "command":
+		args := strings.Fields(kv[1])
+		if len(args) == 0 {
+			return nil, fmt.Errorf("invalid credential source %q, the command is missing", spec)
+		}
+		return goblue.CommandCredentials(args[0], args[1:]...), nil
+	case "file":
+		return goblue.FileCredentials(kv[1], passphrase), nil
+	}
+	return nil, fmt.Errorf("unknown credential source %q, use env, command or file", kv[0])
+}
+
+// passphrase returns the passphrase of the credentials file from the
+// environment or asks for it on the terminal.
+func passphrase() ([]byte, error) {
+	if p := os.Getenv(envPassphrase); p != "" {
+		return []byte(p), nil
+	}
+	fmt.Fprint(os.Stderr, "passphrase: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return nil, fmt.Errorf("reading passphrase: %w", err)
+	}
+	return []byte(strings.TrimRight(line, "\r\n")), nil
+}
+
+// errPlaintext is returned when saving would write the password or pin
+// unencrypted.
+var errPlaintext = errors.New("refusing to save the password and pin in plain text, " +
+	"store them with goblue credentials encrypt and -credentials file:PATH or pass -plaintext")
+
+// saveConfig writes cfg to path, readable by the current user only. The
+// password and pin are left out if they are read from a credential source,
+// without one they are written only if plaintext is set.
+func saveConfig(path string, cfg config, plaintext bool) error {
+	switch {
+	case cfg.CredentialSource != "":
+		cfg.Password, cfg.Pin = "", ""
+	case (cfg.Password != "" || cfg.Pin != "") && !plaintext:
+		return errPlaintext
+	}
+	raw, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(raw, '\n'), 0600)
+}
@@ -0,0 +1,16 @@
+package main
+
+import "testing"
+
+func TestCredentialSource(t *testing.T) {
+	for _, spec := range []string{"env:GOBLUE", "command:pass show kia", "file:/tmp/credentials.json"} {
+		if _, err := credentialSource(spec); err != nil {
+			t.Errorf("%q: %v", spec, err)
+		}
+	}
+	for _, spec := range []string{"", "env", "env:", "command: ", "command:\t", "vault:kia"} {
+		if _, err := credentialSource(spec); err == nil {
+			t.Errorf("%q: accepted invalid source", spec)
+		}
+	}
+}
@@ -0,0 +1,504 @@
+// Command goblue controls Hyundai and Kia vehicles through the Bluelink api.
+//
+//	goblue [flags] <command> [args]
+//
+// The account is read from the config file, GOBLUE_* environment variables
+// and flags, in that order. Run goblue -help for the list of commands.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/frzifus/goblue"
+)
+
+const usage = `usage: goblue [flags] <command> [args]
+
+commands:
+  login [-save [-plaintext]] verify the credentials, -save writes them to the config file
+  vehicles                   list the vehicles of the account
+  status                     show the status of the vehicles
+  lock                       lock the doors
+  unlock                     unlock the doors
+  climate start [flags]      start the climate control, see goblue climate start -help
+  climate stop               stop the climate control
+  charge start|stop          start or stop charging
+  location                   show where the vehicles are parked
+  odometer                   show the total distance driven
+  credentials encrypt -file PATH
+                             write the credentials to a file encrypted by a passphrase
+  mqtt -broker URL [flags]   bridge the vehicles to an MQTT broker, see goblue mqtt -help
+  serve -api-key KEY [flags] serve the vehicles as a local REST api, see goblue serve -help
+
+flags:
+`
+
+var commands = map[string]func(*app, []string) error{
+	"login":       (*app).login,
+	"vehicles":    (*app).vehicles,
+	"status":      (*app).status,
+	"lock":        (*app).lock,
+	"unlock":      (*app).unlock,
+	"climate":     (*app).climate,
+	"charge":      (*app).charge,
+	"location":    (*app).location,
+	"odometer":    (*app).odometer,
+	"credentials": (*app).credentials,
+	"mqtt":        (*app).mqtt,
+	"serve":       (*app).serve,
+}
+
+// errUsage reports invalid arguments, the usage has already been printed.
+var errUsage = errors.New("invalid arguments")
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		if errors.Is(err, errUsage) {
+			os.Exit(2)
+		}
+		log.New(os.Stderr, "", 0).Fatalln(err)
+	}
+}
+
+// run parses the global flags and runs the command named by args. Nothing is
+// sent to the api before all global flags were validated.
+func run(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("goblue", flag.ContinueOnError)
+	var (
+		configPath = fs.String("config", defaultConfigPath(), "config file, also set by "+envConfig)
+		username   = fs.String("username", "", "account username, also set by "+envUsername)
+		password   = fs.String("password", "", "account password, also set by "+envPassword)
+		pin        = fs.String("pin", "", "pin required by remote commands, also set by "+envPin)
+		brand      = fs.String("brand", "", "hyundai or kia, also set by "+envBrand)
+		region     = fs.String("region", "", "account region, also set by "+envRegion)
+		creds      = fs.String("credentials", "", "read the secrets from env:PREFIX, command:CMD or file:PATH, also set by "+envCredentials)
+		vin        = fs.String("vin", "", "vehicle to use, required by commands if the account has several")
+		output     = fs.String("o", formatTable, "output format, table or json")
+		baseURL    = fs.String("base-url", "", "override the api url of the brand")
+		timeout    = fs.Duration("timeout", 2*time.Minute, "timeout of api requests")
+		verbose    = fs.Bool("v", false, "log api calls to stderr")
+	)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return errUsage
+	}
+
+	cmd, ok := commands[fs.Arg(0)]
+	if !ok {
+		fs.Usage()
+		return errUsage
+	}
+	out, err := newPrinter(stdout, *output)
+	if err != nil {
+		return err
+	}
+
+	explicit := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "config" {
+			explicit = true
+		}
+	})
+	cfg, err := loadConfig(*configPath, explicit, config{
+		Config: goblue.Config{
+			Username: *username,
+			Password: *password,
+			Pin:      *pin,
+			Brand:    goblue.Brand(strings.ToLower(*brand)),
+			Region:   goblue.Region(strings.ToLower(*region)),
+		},
+		CredentialSource: *creds,
+	})
+	if err != nil {
+		return fmt.Errorf("reading config failed: %w", err)
+	}
+
+	opts := []goblue.ClientOptions{goblue.WithTimeout(*timeout)}
+	if *baseURL != "" {
+		opts = append(opts, goblue.WithBaseURL(*baseURL))
+	}
+	if *verbose {
+		opts = append(opts, goblue.WithLogger(goblue.NewLogger(log.New(os.Stderr, "bluelink-api: ", 0), true)))
+	}
+	client, err := goblue.NewClient(cfg.Config, opts...)
+	if err != nil {
+		return fmt.Errorf("creating client failed: %w", err)
+	}
+
+	a := &app{
+		client:     client,
+		cfg:        cfg,
+		configPath: *configPath,
+		vin:        *vin,
+		verbose:    *verbose,
+		out:        out,
+	}
+	return cmd(a, fs.Args()[1:])
+}
+
+// app holds the state shared by all commands.
+type app struct {
+	client     *goblue.Client
+	cfg        config
+	configPath string
+	vin        string
+	verbose    bool
+	out        *printer
+}
+
+// selectVehicles authenticates and returns the vehicle selected by -vin or
+// all vehicles of the account.
+func (a *app) selectVehicles() ([]*goblue.Vehicle, error) {
+	if err := a.client.Authenticate(); err != nil {
+		return nil, fmt.Errorf("authentication failed: %w", err)
+	}
+	vs, err := a.client.Vehicles()
+	if err != nil {
+		return nil, err
+	}
+	if a.vin == "" {
+		return vs, nil
+	}
+	for _, v := range vs {
+		if strings.EqualFold(v.VIN(), a.vin) {
+			return []*goblue.Vehicle{v}, nil
+		}
+	}
+	return nil, fmt.Errorf("vehicle %s: %w", a.vin, goblue.ErrNoVehicleFound)
+}
+
+// selectVehicle returns the single vehicle a remote command is sent to.
+func (a *app) selectVehicle() (*goblue.Vehicle, error) {
+	vs, err := a.selectVehicles()
+	if err != nil {
+		return nil, err
+	}
+	switch len(vs) {
+	case 0:
+		return nil, goblue.ErrNoVehicleFound
+	case 1:
+		return vs[0], nil
+	}
+	vins := make([]string, 0, len(vs))
+	for _, v := range vs {
+		vins = append(vins, v.VIN())
+	}
+	return nil, fmt.Errorf("the account has several vehicles, select one with -vin: %s", strings.Join(vins, ", "))
+}
+
+// parseFlags parses the arguments of a command without flags of its own.
+func parseFlags(name string, args []string) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(fs.Output(), "%s takes no arguments\n", name)
+		return errUsage
+	}
+	return nil
+}
+
+func (a *app) login(args []string) error {
+	fs := flag.NewFlagSet("login", flag.ContinueOnError)
+	save := fs.Bool("save", false, "write the credentials to the config file")
+	plaintext := fs.Bool("plaintext", false, "let -save write the password and pin unencrypted")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+
+	if err := a.client.Authenticate(); err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+	if *save {
+		if err := saveConfig(a.configPath, a.cfg, *plaintext); err != nil {
+			return err
+		}
+	}
+
+	result := struct {
+		Username string        `json:"username,omitempty"`
+		Brand    goblue.Brand  `json:"brand"`
+		Region   goblue.Region `json:"region"`
+	}{a.cfg.Username, a.cfg.Brand, a.cfg.Region}
+	return a.out.print(result, func(w io.Writer) {
+		if result.Username == "" {
+			fmt.Fprintf(w, "authenticated (%s, %s)\n", result.Brand, result.Region)
+		} else {
+			fmt.Fprintf(w, "authenticated as %s (%s, %s)\n", result.Username, result.Brand, result.Region)
+		}
+		if *save {
+			fmt.Fprintf(w, "saved config to %s\n", a.configPath)
+		}
+	})
+}
+
+type vehicleResult struct {
+	VIN          string `json:"vin"`
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+	Model        string `json:"model"`
+	Capabilities string `json:"capabilities"`
+}
+
+func (a *app) vehicles(args []string) error {
+	if err := parseFlags("vehicles", args); err != nil {
+		return err
+	}
+	vs, err := a.selectVehicles()
+	if err != nil {
+		return err
+	}
+
+	results := []vehicleResult{}
+	for _, v := range vs {
+		results = append(results, vehicleResult{
+			VIN:          v.VIN(),
+			ID:           v.ID(),
+			Name:         v.Name(),
+			Type:         v.Type(),
+			Model:        v.Info().ModelName,
+			Capabilities: v.Capabilities().String(),
+		})
+	}
+	return a.out.print(results, func(w io.Writer) {
+		fmt.Fprintln(w, "VIN\tNAME\tTYPE\tMODEL\tCAPABILITIES")
+		for _, r := range results {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.VIN, r.Name, r.Type, r.Model, r.Capabilities)
+		}
+	})
+}
+
+type statusResult struct {
+	VIN    string                `json:"vin"`
+	Status *goblue.VehicleStatus `json:"status"`
+}
+
+func (a *app) status(args []string) error {
+	if err := parseFlags("status", args); err != nil {
+		return err
+	}
+	vs, err := a.selectVehicles()
+	if err != nil {
+		return err
+	}
+
+	results := []statusResult{}
+	for _, v := range vs {
+		st, err := v.Status()
+		if err != nil {
+			return fmt.Errorf("%s: %w", v.VIN(), err)
+		}
+		results = append(results, statusResult{VIN: v.VIN(), Status: st})
+	}
+	return a.out.print(results, func(w io.Writer) {
+		fmt.Fprintln(w, "VIN\tUPDATED\tLOCKED\tCHARGING\tSOC\tRANGE\tWINDOWS OPEN")
+		for _, r := range results {
+			st := r.Status
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d%%\t%d km\t%s\n",
+				r.VIN, st.UpdatedAt().Local().Format(time.RFC3339), yesNo(st.DoorIsLocked()),
+				yesNo(st.IsCharging()), st.SoC(), st.RangeLeft(), yesNo(st.Windows().Open()))
+		}
+	})
+}
+
+type commandResult struct {
+	VIN     string `json:"vin"`
+	Command string `json:"command"`
+}
+
+// command sends a remote command to the selected vehicle and reports its
+// success.
+func (a *app) command(name string, send func(*goblue.Vehicle) error) error {
+	v, err := a.selectVehicle()
+	if err != nil {
+		return err
+	}
+	if err := send(v); err != nil {
+		return fmt.Errorf("%s %s: %w", v.VIN(), name, err)
+	}
+
+	result := commandResult{VIN: v.VIN(), Command: name}
+	return a.out.print(result, func(w io.Writer) {
+		fmt.Fprintf(w, "%s\t%s\tok\n", result.VIN, result.Command)
+	})
+}
+
+func (a *app) lock(args []string) error {
+	if err := parseFlags("lock", args); err != nil {
+		return err
+	}
+	return a.command("lock", (*goblue.Vehicle).Lock)
+}
+
+func (a *app) unlock(args []string) error {
+	if err := parseFlags("unlock", args); err != nil {
+		return err
+	}
+	return a.command("unlock", (*goblue.Vehicle).Unlock)
+}
+
+func (a *app) climate(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: goblue climate start|stop")
+		return errUsage
+	}
+
+	switch args[0] {
+	case "start":
+		fs := flag.NewFlagSet("climate start", flag.ContinueOnError)
+		temperature := fs.Float64("temp", goblue.DefaultTemperature, "target temperature in celsius")
+		defrost := fs.Bool("defrost", false, "enable the windshield defroster")
+		heating := fs.Bool("heating", false, "heat the rear window and side mirrors")
+		if err := fs.Parse(args[1:]); err != nil {
+			return errUsage
+		}
+		opts := goblue.StartOptions{Temperature: *temperature, Defrost: *defrost, Heating: *heating}
+		return a.command("climate start", func(v *goblue.Vehicle) error {
+			return v.Start(opts)
+		})
+	case "stop":
+		if err := parseFlags("climate stop", args[1:]); err != nil {
+			return err
+		}
+		return a.command("climate stop", (*goblue.Vehicle).Stop)
+	}
+	fmt.Fprintln(os.Stderr, "usage: goblue climate start|stop")
+	return errUsage
+}
+
+func (a *app) charge(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: goblue charge start|stop")
+		return errUsage
+	}
+
+	switch args[0] {
+	case "start":
+		if err := parseFlags("charge start", args[1:]); err != nil {
+			return err
+		}
+		return a.command("charge start", (*goblue.Vehicle).StartCharge)
+	case "stop":
+		if err := parseFlags("charge stop", args[1:]); err != nil {
+			return err
+		}
+		return a.command("charge stop", (*goblue.Vehicle).StopCharge)
+	}
+	fmt.Fprintln(os.Stderr, "usage: goblue charge start|stop")
+	return errUsage
+}
+
+type locationResult struct {
+	VIN      string           `json:"vin"`
+	Location *goblue.Location `json:"location"`
+}
+
+func (a *app) location(args []string) error {
+	if err := parseFlags("location", args); err != nil {
+		return err
+	}
+	vs, err := a.selectVehicles()
+	if err != nil {
+		return err
+	}
+
+	results := []locationResult{}
+	for _, v := range vs {
+		loc, err := v.Position()
+		if err != nil {
+			return fmt.Errorf("%s: %w", v.VIN(), err)
+		}
+		results = append(results, locationResult{VIN: v.VIN(), Location: loc})
+	}
+	return a.out.print(results, func(w io.Writer) {
+		fmt.Fprintln(w, "VIN\tLATITUDE\tLONGITUDE\tUPDATED")
+		for _, r := range results {
+			fmt.Fprintf(w, "%s\t%.6f\t%.6f\t%s\n", r.VIN, r.Location.Latitude, r.Location.Longitude,
+				r.Location.UpdatedAt.Local().Format(time.RFC3339))
+		}
+	})
+}
+
+type odometerResult struct {
+	VIN      string  `json:"vin"`
+	Odometer float64 `json:"odometer"`
+}
+
+func (a *app) odometer(args []string) error {
+	if err := parseFlags("odometer", args); err != nil {
+		return err
+	}
+	vs, err := a.selectVehicles()
+	if err != nil {
+		return err
+	}
+
+	results := []odometerResult{}
+	for _, v := range vs {
+		km, err := v.OdometerKm()
+		if err != nil {
+			return fmt.Errorf("%s: %w", v.VIN(), err)
+		}
+		results = append(results, odometerResult{VIN: v.VIN(), Odometer: km})
+	}
+	return a.out.print(results, func(w io.Writer) {
+		fmt.Fprintln(w, "VIN\tODOMETER")
+		for _, r := range results {
+			fmt.Fprintf(w, "%s\t%.0f km\n", r.VIN, r.Odometer)
+		}
+	})
+}
+
+func (a *app) credentials(args []string) error {
+	if len(args) == 0 || args[0] != "encrypt" {
+		fmt.Fprintln(os.Stderr, "usage: goblue credentials encrypt -file PATH")
+		return errUsage
+	}
+
+	fs := flag.NewFlagSet("credentials encrypt", flag.ContinueOnError)
+	file := fs.String("file", "", "encrypted credentials file to write")
+	if err := fs.Parse(args[1:]); err != nil {
+		return errUsage
+	}
+	if *file == "" {
+		fs.Usage()
+		return errUsage
+	}
+
+	// secrets only the config holds, like a pin, are kept
+	creds, err := a.cfg.ResolveCredentials()
+	if err != nil {
+		return err
+	}
+	pass, err := passphrase()
+	if err != nil {
+		return err
+	}
+	if len(pass) == 0 {
+		return errors.New("empty passphrase")
+	}
+	if err := goblue.WriteCredentialsFile(*file, creds, pass); err != nil {
+		return err
+	}
+
+	return a.out.print(struct {
+		File string `json:"file"`
+	}{*file}, func(w io.Writer) {
+		fmt.Fprintf(w, "wrote encrypted credentials to %s, use -credentials file:%s\n", *file, *file)
+	})
+}
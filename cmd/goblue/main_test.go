@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/frzifus/goblue"
+	"github.com/frzifus/goblue/bluelinktest"
+)
+
+// runAgainst runs the command line against the fake server with the
+// account flags set.
+func runAgainst(t *testing.T, srv *bluelinktest.Server, args ...string) (string, error) {
+	t.Helper()
+	config := filepath.Join(t.TempDir(), "config.json")
+	if err := ioutil.WriteFile(config, []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	flags := []string{
+		"-config", config,
+		"-username", "user", "-password", "secret", "-pin", "1234",
+		"-brand", "kia", "-region", "eu",
+		"-base-url", srv.URL,
+	}
+	var out bytes.Buffer
+	err := run(append(flags, args...), &out)
+	return out.String(), err
+}
+
+func TestInvalidOutputFormatSendsNoCommand(t *testing.T) {
+	srv := bluelinktest.NewServer(bluelinktest.Credentials{Username: "user", Password: "secret", PIN: "1234"})
+	defer srv.Close()
+	srv.AddVehicle(bluelinktest.Vehicle{ID: "00000000-0000-0000-0000-0000000000a1", VIN: "KMHTEST0000000001", Type: "EV"})
+
+	if _, err := runAgainst(t, srv, "-o", "yaml", "unlock"); err == nil {
+		t.Fatal("invalid output format accepted")
+	}
+	if cmds := srv.Commands(); len(cmds) != 0 {
+		t.Fatalf("invalid output format sent commands %+v", cmds)
+	}
+
+	out, err := runAgainst(t, srv, "-o", "json", "unlock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmds := srv.Commands(); len(cmds) != 1 || cmds[0].Name != "door" {
+		t.Errorf("got commands %+v, want one unlock", cmds)
+	}
+	if !strings.Contains(out, `"command": "unlock"`) {
+		t.Errorf("unexpected output %q", out)
+	}
+}
+
+func TestCredentialsEncryptKeepsConfigSecrets(t *testing.T) {
+	srv := bluelinktest.NewServer(bluelinktest.Credentials{Username: "user", Password: "secret", PIN: "1234"})
+	defer srv.Close()
+
+	// the source provides the password only, the pin is taken from -pin
+	os.Setenv("GOBLUE_TEST_PASSWORD", "from-source")
+	defer os.Unsetenv("GOBLUE_TEST_PASSWORD")
+	os.Setenv(envPassphrase, "passphrase")
+	defer os.Unsetenv(envPassphrase)
+
+	file := filepath.Join(t.TempDir(), "credentials")
+	if _, err := runAgainst(t, srv, "-credentials", "env:GOBLUE_TEST", "credentials", "encrypt", "-file", file); err != nil {
+		t.Fatal(err)
+	}
+	creds, err := goblue.FileCredentials(file, passphrase).Credentials()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (goblue.Credentials{Username: "user", Password: "from-source", Pin: "1234"}); creds != want {
+		t.Errorf("encrypted %+v, want %+v", creds, want)
+	}
+}
+
+func TestLoginSaveRefusesPlaintext(t *testing.T) {
+	srv := bluelinktest.NewServer(bluelinktest.Credentials{Username: "user", Password: "secret", PIN: "1234"})
+	defer srv.Close()
+
+	if _, err := runAgainst(t, srv, "login", "-save"); !errors.Is(err, errPlaintext) {
+		t.Errorf("got %v, want errPlaintext", err)
+	}
+	if _, err := runAgainst(t, srv, "login", "-save", "-plaintext"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSaveConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	cfg := config{Config: goblue.Config{Username: "user", Password: "secret", Pin: "1234"}}
+
+	if err := saveConfig(path, cfg, false); !errors.Is(err, errPlaintext) {
+		t.Fatalf("got %v, want errPlaintext", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("config written although refused: %v", err)
+	}
+
+	cfg.CredentialSource = "env:GOBLUE"
+	if err := saveConfig(path, cfg, false); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(raw), "secret") || strings.Contains(string(raw), "1234") {
+		t.Errorf("secrets saved along the credential source:\n%s", raw)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/frzifus/goblue"
+	"github.com/frzifus/goblue/mqtt"
+)
+
+// mqtt bridges the selected vehicles to a broker until it is interrupted.
+func (a *app) mqtt(args []string) error {
+	fs := flag.NewFlagSet("mqtt", flag.ContinueOnError)
+	broker := fs.String("broker", "", "broker url, e.g. tcp://localhost:1883")
+	clientID := fs.String("client-id", "goblue", "client id used at the broker")
+	username := fs.String("mqtt-username", "", "broker username")
+	password := fs.String("mqtt-password", "", "broker password, also set by "+envMQTTPassword)
+	prefix := fs.String("prefix", mqtt.DefaultTopicPrefix, "prefix of the state and command topics")
+	discovery := fs.String("discovery-prefix", mqtt.DefaultDiscoveryPrefix, "Home Assistant discovery prefix, empty disables discovery")
+	interval := fs.Duration("interval", mqtt.DefaultInterval, "time between two status polls")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if *broker == "" || fs.NArg() > 0 {
+		fs.Usage()
+		return errUsage
+	}
+	if *password == "" {
+		*password = os.Getenv(envMQTTPassword)
+	}
+
+	vs, err := a.selectVehicles()
+	if err != nil {
+		return err
+	}
+	if len(vs) == 0 {
+		return goblue.ErrNoVehicleFound
+	}
+
+	opts := paho.NewClientOptions().
+		AddBroker(*broker).
+		SetClientID(*clientID).
+		SetUsername(*username).
+		SetPassword(*password).
+		SetAutoReconnect(true)
+
+	bridge := mqtt.NewBridge(opts, a.client, vs,
+		mqtt.WithTopicPrefix(*prefix),
+		mqtt.WithDiscoveryPrefix(*discovery),
+		mqtt.WithInterval(*interval),
+		mqtt.WithLogger(goblue.NewLogger(log.New(os.Stderr, "mqtt: ", 0), a.verbose)),
+	)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Fprintf(os.Stderr, "bridging %d vehicles to %s\n", len(vs), *broker)
+	if err := bridge.Run(ctx); !errors.Is(err, context.Canceled) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/frzifus/goblue"
+	"github.com/frzifus/goblue/rest"
+)
+
+const shutdownTimeout = 10 * time.Second
+
+// serve runs the local REST api until it is interrupted.
+func (a *app) serve(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("addr", "127.0.0.1:8080", "address to listen on")
+	apiKeys := fs.String("api-key", "", "comma separated api keys accepted by the server, also set by "+envAPIKey)
+	statusTTL := fs.Duration("status-ttl", rest.DefaultStatusTTL, "time the status and location of a vehicle are cached")
+	vehiclesTTL := fs.Duration("vehicles-ttl", rest.DefaultVehiclesTTL, "time the vehicles of the account are cached")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return errUsage
+	}
+	if *apiKeys == "" {
+		*apiKeys = os.Getenv(envAPIKey)
+	}
+	if len(splitKeys(*apiKeys)) == 0 {
+		return fmt.Errorf("an api key is required, set -api-key or %s", envAPIKey)
+	}
+
+	if err := a.client.Authenticate(); err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	handler := rest.NewHandler(a.client,
+		rest.WithAPIKeys(splitKeys(*apiKeys)...),
+		rest.WithStatusTTL(*statusTTL),
+		rest.WithVehiclesTTL(*vehiclesTTL),
+		rest.WithLogger(goblue.NewLogger(log.New(os.Stderr, "serve: ", 0), a.verbose)),
+	)
+	srv := &http.Server{Addr: *addr, Handler: handler}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- srv.ListenAndServe()
+	}()
+	fmt.Fprintf(os.Stderr, "serving on http://%s\n", *addr)
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// splitKeys splits a comma separated list of api keys. Blanks around the
+// keys and empty keys are dropped.
+func splitKeys(list string) []string {
+	var keys []string
+	for _, k := range strings.Split(list, ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
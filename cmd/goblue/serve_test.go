@@ -0,0 +1,22 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitKeys(t *testing.T) {
+	tests := map[string][]string{
+		"a":          {"a"},
+		"a,b":        {"a", "b"},
+		"a, b":       {"a", "b"},
+		" a ,\tb , ": {"a", "b"},
+		",,":         nil,
+		"":           nil,
+	}
+	for list, want := range tests {
+		if got := splitKeys(list); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: got %q, want %q", list, got, want)
+		}
+	}
+}
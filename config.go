@@ -6,4 +6,33 @@ type Config struct {
 	Pin      string `json:"pin"`
 	Brand    Brand  `json:"brand"`
 	Region   Region `json:"region"`
+
+	// Credentials, if set, is asked for the username, password and pin on
+	// each authentication. Credentials it leaves empty are taken from the
+	// fields above.
+	Credentials CredentialSource `json:"-"`
+}
+
+// ResolveCredentials returns the account secrets of the config, the fields
+// overridden by those the credential source provides.
+func (c Config) ResolveCredentials() (Credentials, error) {
+	creds := Credentials{Username: c.Username, Password: c.Password, Pin: c.Pin}
+	if c.Credentials == nil {
+		return creds, nil
+	}
+
+	src, err := c.Credentials.Credentials()
+	if err != nil {
+		return creds, err
+	}
+	if src.Username != "" {
+		creds.Username = src.Username
+	}
+	if src.Password != "" {
+		creds.Password = src.Password
+	}
+	if src.Pin != "" {
+		creds.Pin = src.Pin
+	}
+	return creds, nil
 }
@@ -0,0 +1,358 @@
+package goblue
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+)
+
+const (
+	commandOn    = "on"
+	commandClose = "close"
+	commandVent  = "vent"
+	commandOpen  = "open"
+	commandStart = "start"
+	commandStop  = "stop"
+
+	commandResultSuccess     = "success"
+	commandResultFail        = "fail"
+	commandResultNonResponse = "non-response"
+
+	commandPollInterval = 2 * time.Second
+	commandTimeout      = time.Minute
+)
+
+// HornAndLights lets the vehicle honk and flash its lights.
+func (v *Vehicle) HornAndLights() (err error) {
+	return v.HornAndLightsContext(context.Background())
+}
+
+// HornAndLightsContext is like HornAndLights, using ctx for the requests and
+// the parent span.
+func (v *Vehicle) HornAndLightsContext(ctx context.Context) (err error) {
+	ctx, op := v.startOperation(ctx, "goblue.Vehicle.HornAndLights")
+	defer op.end(&err)
+
+	if err := v.require(CapabilityCCS2); err != nil {
+		return err
+	}
+	return v.control(ctx, v.endpoints.HornLight, map[string]interface{}{"command": commandOn})
+}
+
+// LightsOnly flashes the hazard lights of the vehicle.
+func (v *Vehicle) LightsOnly() (err error) {
+	return v.LightsOnlyContext(context.Background())
+}
+
+// LightsOnlyContext is like LightsOnly, using ctx for the requests and the
+// parent span.
+func (v *Vehicle) LightsOnlyContext(ctx context.Context) (err error) {
+	ctx, op := v.startOperation(ctx, "goblue.Vehicle.LightsOnly")
+	defer op.end(&err)
+
+	if err := v.require(CapabilityCCS2); err != nil {
+		return err
+	}
+	return v.control(ctx, v.endpoints.Light, map[string]interface{}{"command": commandOn})
+}
+
+// CloseWindows closes all windows of the vehicle.
+func (v *Vehicle) CloseWindows() (err error) {
+	return v.CloseWindowsContext(context.Background())
+}
+
+// CloseWindowsContext is like CloseWindows, using ctx for the requests and the
+// parent span.
+func (v *Vehicle) CloseWindowsContext(ctx context.Context) (err error) {
+	ctx, op := v.startOperation(ctx, "goblue.Vehicle.CloseWindows")
+	defer op.end(&err)
+
+	if err := v.require(CapabilityWindowControl); err != nil {
+		return err
+	}
+	return v.command(ctx, v.endpoints.Window, v.endpoints.CCS2Window, commandClose)
+}
+
+// VentWindows opens all windows of the vehicle to the vent position.
+func (v *Vehicle) VentWindows() (err error) {
+	return v.VentWindowsContext(context.Background())
+}
+
+// VentWindowsContext is like VentWindows, using ctx for the requests and the
+// parent span.
+func (v *Vehicle) VentWindowsContext(ctx context.Context) (err error) {
+	ctx, op := v.startOperation(ctx, "goblue.Vehicle.VentWindows")
+	defer op.end(&err)
+
+	if err := v.require(CapabilityWindowControl); err != nil {
+		return err
+	}
+	return v.command(ctx, v.endpoints.Window, v.endpoints.CCS2Window, commandVent)
+}
+
+// Lock locks the doors of the vehicle.
+func (v *Vehicle) Lock() (err error) {
+	return v.LockContext(context.Background())
+}
+
+// LockContext is like Lock, using ctx for the requests and the parent span.
+func (v *Vehicle) LockContext(ctx context.Context) (err error) {
+	ctx, op := v.startOperation(ctx, "goblue.Vehicle.Lock")
+	defer op.end(&err)
+
+	return v.command(ctx, v.endpoints.Door, v.endpoints.CCS2Door, commandClose)
+}
+
+// Unlock unlocks the doors of the vehicle.
+func (v *Vehicle) Unlock() (err error) {
+	return v.UnlockContext(context.Background())
+}
+
+// UnlockContext is like Unlock, using ctx for the requests and the parent
+// span.
+func (v *Vehicle) UnlockContext(ctx context.Context) (err error) {
+	ctx, op := v.startOperation(ctx, "goblue.Vehicle.Unlock")
+	defer op.end(&err)
+
+	return v.command(ctx, v.endpoints.Door, v.endpoints.CCS2Door, commandOpen)
+}
+
+// Start starts the climate control of the vehicle. Without options the
+// cabin is conditioned to DefaultTemperature.
+func (v *Vehicle) Start(opts ...StartOptions) (err error) {
+	return v.StartContext(context.Background(), opts...)
+}
+
+// StartContext is like Start, using ctx for the requests and the parent span.
+func (v *Vehicle) StartContext(ctx context.Context, opts ...StartOptions) (err error) {
+	ctx, op := v.startOperation(ctx, "goblue.Vehicle.Start")
+	defer op.end(&err)
+
+	o := StartOptions{Temperature: DefaultTemperature}
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.Temperature == 0 {
+		o.Temperature = DefaultTemperature
+	}
+	if o.Temperature < minTemperature || o.Temperature > maxTemperature {
+		return fmt.Errorf("%w: %.1f not in [%.1f, %.1f]", ErrInvalidTemperature, o.Temperature, minTemperature, maxTemperature)
+	}
+
+	if v.Capabilities().Has(CapabilityCCS2) {
+		return v.control(ctx, v.endpoints.CCS2Climate, map[string]interface{}{
+			"command":                   commandStart,
+			"windshieldFrontDefogState": o.Defrost,
+			"hvacTempType":              1,
+			"heating1":                  flag(o.Heating),
+			"tempUnit":                  "C",
+			"drvSeatLoc":                "L",
+			"hvacTemp":                  o.Temperature,
+		})
+	}
+	return v.control(ctx, v.endpoints.Climate, map[string]interface{}{
+		"action":   commandStart,
+		"hvacType": 0,
+		"options": map[string]interface{}{
+			"defrost":  o.Defrost,
+			"heating1": flag(o.Heating),
+		},
+		"tempCode": temperatureCode(o.Temperature),
+		"unit":     "C",
+	})
+}
+
+// Stop stops the climate control of the vehicle.
+func (v *Vehicle) Stop() (err error) {
+	return v.StopContext(context.Background())
+}
+
+// StopContext is like Stop, using ctx for the requests and the parent span.
+func (v *Vehicle) StopContext(ctx context.Context) (err error) {
+	ctx, op := v.startOperation(ctx, "goblue.Vehicle.Stop")
+	defer op.end(&err)
+
+	if v.Capabilities().Has(CapabilityCCS2) {
+		return v.control(ctx, v.endpoints.CCS2Climate, map[string]interface{}{"command": commandStop})
+	}
+	return v.control(ctx, v.endpoints.Climate, map[string]interface{}{
+		"action":   commandStop,
+		"hvacType": 0,
+		"options": map[string]interface{}{
+			"defrost":  false,
+			"heating1": 0,
+		},
+		"tempCode": temperatureCode(DefaultTemperature),
+		"unit":     "C",
+	})
+}
+
+// StartCharge starts charging a plugged in vehicle.
+func (v *Vehicle) StartCharge() (err error) {
+	return v.StartChargeContext(context.Background())
+}
+
+// StartChargeContext is like StartCharge, using ctx for the requests and the
+// parent span.
+func (v *Vehicle) StartChargeContext(ctx context.Context) (err error) {
+	ctx, op := v.startOperation(ctx, "goblue.Vehicle.StartCharge")
+	defer op.end(&err)
+
+	if err := v.require(CapabilityCharging); err != nil {
+		return err
+	}
+	return v.command(ctx, v.endpoints.Charge, v.endpoints.CCS2Charge, commandStart)
+}
+
+// StopCharge stops charging the vehicle.
+func (v *Vehicle) StopCharge() (err error) {
+	return v.StopChargeContext(context.Background())
+}
+
+// StopChargeContext is like StopCharge, using ctx for the requests and the
+// parent span.
+func (v *Vehicle) StopChargeContext(ctx context.Context) (err error) {
+	ctx, op := v.startOperation(ctx, "goblue.Vehicle.StopCharge")
+	defer op.end(&err)
+
+	if err := v.require(CapabilityCharging); err != nil {
+		return err
+	}
+	return v.command(ctx, v.endpoints.Charge, v.endpoints.CCS2Charge, commandStop)
+}
+
+// command sends a simple remote command to the classic or the ccs2 endpoint,
+// depending on the protocol spoken by the vehicle.
+func (v *Vehicle) command(ctx context.Context, endpoint, ccs2Endpoint, action string) error {
+	if v.Capabilities().Has(CapabilityCCS2) {
+		return v.control(ctx, ccs2Endpoint, map[string]interface{}{"command": action})
+	}
+	return v.control(ctx, endpoint, map[string]interface{}{
+		"action":   action,
+		"deviceId": v.auth.session().DeviceID,
+	})
+}
+
+// temperatureCode encodes a temperature in celsius as expected by the classic
+// climate endpoint, the hex index in half degree steps above minTemperature.
+func temperatureCode(t float64) string {
+	return fmt.Sprintf("%02XH", int(math.Round((t-minTemperature)*2)))
+}
+
+func flag(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// control sends a remote command authorized by the control token and waits
+// until the vehicle reports its result.
+func (v *Vehicle) control(ctx context.Context, endpoint string, payload interface{}) error {
+	sess := v.auth.session()
+	token, err := v.requestControlToken(ctx, sess)
+	if err != nil {
+		return err
+	}
+
+	uri := fmt.Sprintf(v.auth.URI+endpoint, v.id)
+	req, err := newJSONRequest(ctx, http.MethodPost, uri, payload, v.auth.spaHeaders(sess, token))
+	if err != nil {
+		return err
+	}
+
+	msgID, err := doJSON(v.http, markCommand(req), nil)
+	if err != nil {
+		return err
+	}
+
+	return v.awaitCommand(ctx, msgID)
+}
+
+// awaitCommand polls the notification records until the command with the
+// given id succeeded, failed, the timeout is exceeded or the context is done.
+func (v *Vehicle) awaitCommand(ctx context.Context, msgID string) error {
+	uri := fmt.Sprintf(v.auth.URI+v.endpoints.Records, v.id)
+	deadline := time.Now().Add(commandTimeout)
+	for {
+		var records []struct {
+			Recordid string `json:"recordId"`
+			Result   string `json:"result"`
+		}
+		if err := v.doSpaRequest(ctx, http.MethodGet, uri, nil, &records); err != nil {
+			return err
+		}
+
+		for _, r := range records {
+			if r.Recordid != msgID {
+				continue
+			}
+			switch r.Result {
+			case commandResultSuccess:
+				return nil
+			case commandResultFail, commandResultNonResponse:
+				return ErrCommandFailed
+			}
+		}
+
+		if time.Now().Add(commandPollInterval).After(deadline) {
+			return ErrCommandTimeout
+		}
+		t := time.NewTimer(commandPollInterval)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// requestControlToken exchanges the pin for a short living token required to
+// send remote commands. The token is cached until it expires or the session
+// is renewed.
+func (v *Vehicle) requestControlToken(ctx context.Context, sess session) (string, error) {
+	if sess.AccessToken == "" {
+		return "", ErrNotAuthenticated
+	}
+
+	v.tokenMu.Lock()
+	defer v.tokenMu.Unlock()
+
+	if v.controlToken != "" && v.controlTokenIssuer == sess.AccessToken &&
+		time.Now().Before(v.controlTokenExpiry) {
+		return v.controlToken, nil
+	}
+
+	data := map[string]interface{}{
+		"deviceId": sess.DeviceID,
+		"pin":      sess.Pin,
+	}
+
+	headers := map[string]string{
+		"Authorization": sess.AccessToken,
+		"User-Agent":    v.auth.UserAgent,
+	}
+
+	uri := v.auth.URI + v.endpoints.Pin
+	req, err := newJSONRequest(ctx, http.MethodPut, uri, data, headers)
+	if err != nil {
+		return "", err
+	}
+
+	msg := struct {
+		Controltoken string `json:"controlToken"`
+		Expirestime  int    `json:"expiresTime"`
+	}{}
+	if err := doRawJSON(v.http, req, &msg); err != nil {
+		return "", err
+	}
+
+	v.controlToken = "Bearer " + msg.Controltoken
+	v.controlTokenIssuer = sess.AccessToken
+	v.controlTokenExpiry = time.Now().Add(time.Duration(msg.Expirestime) * time.Second)
+
+	return v.controlToken, nil
+}
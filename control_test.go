@@ -0,0 +1,154 @@
+package goblue_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/frzifus/goblue"
+	"github.com/frzifus/goblue/bluelinktest"
+)
+
+// newControlVehicle returns the server and the first vehicle of a client
+// authenticated with the pin.
+func newControlVehicle(t *testing.T, pin string, v bluelinktest.Vehicle) (*bluelinktest.Server, *goblue.Vehicle) {
+	t.Helper()
+	srv := bluelinktest.NewServer(bluelinktest.Credentials{Username: "user", Password: "secret", PIN: "1234"})
+	t.Cleanup(srv.Close)
+	srv.AddVehicle(v)
+
+	cfg := srv.Config(goblue.BrandKia)
+	cfg.Pin = pin
+	c, err := goblue.NewClient(cfg, srv.ClientOptions()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Authenticate(); err != nil {
+		t.Fatal(err)
+	}
+	vs, err := c.Vehicles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv, vs[0]
+}
+
+func TestControlInvalidPin(t *testing.T) {
+	srv, v := newControlVehicle(t, "0000", bluelinktest.Vehicle{ID: "00000000-0000-0000-0000-0000000000f1", VIN: "VIN-1", Type: "EV"})
+	err := v.Unlock()
+	var apiErr *goblue.APIError
+	if !errors.Is(err, goblue.ErrInvalidPIN) || !errors.As(err, &apiErr) || apiErr.ResCode != "4003" {
+		t.Errorf("got %v, want ErrInvalidPIN", err)
+	}
+	if cmds := srv.Commands(); len(cmds) != 0 {
+		t.Errorf("command sent with an invalid pin: %+v", cmds)
+	}
+
+	// other failures of the pin endpoint are not blamed on the pin
+	srv, v = newControlVehicle(t, "1234", bluelinktest.Vehicle{ID: "00000000-0000-0000-0000-0000000000f1", VIN: "VIN-1", Type: "EV"})
+	srv.Fail(bluelinktest.EndpointPin, bluelinktest.Failure{StatusCode: 400, ResCode: "4004", Message: "Duplicate request"}, 1)
+	err = v.Unlock()
+	if err == nil || errors.Is(err, goblue.ErrInvalidPIN) {
+		t.Errorf("got %v, want an api error other than ErrInvalidPIN", err)
+	}
+	if err := v.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHornAndLights(t *testing.T) {
+	srv, v := newControlVehicle(t, "1234", bluelinktest.Vehicle{ID: "00000000-0000-0000-0000-0000000000f2", VIN: "VIN-2", Type: "EV", CCS2: true})
+	if err := v.HornAndLights(); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.LightsOnly(); err != nil {
+		t.Fatal(err)
+	}
+	want := []bluelinktest.Command{
+		{VehicleID: "00000000-0000-0000-0000-0000000000f2", Name: "hornlight", Payload: map[string]interface{}{"command": "on"}},
+		{VehicleID: "00000000-0000-0000-0000-0000000000f2", Name: "light", Payload: map[string]interface{}{"command": "on"}},
+	}
+	if got := srv.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got commands %+v, want %+v", got, want)
+	}
+
+	srv, v = newControlVehicle(t, "1234", bluelinktest.Vehicle{ID: "00000000-0000-0000-0000-0000000000f3", VIN: "VIN-3", Type: "EV"})
+	for name, send := range map[string]func() error{"HornAndLights": v.HornAndLights, "LightsOnly": v.LightsOnly} {
+		if err := send(); !errors.Is(err, goblue.ErrUnsupported) {
+			t.Errorf("%s: got %v, want ErrUnsupported", name, err)
+		}
+	}
+	if cmds := srv.Commands(); len(cmds) != 0 {
+		t.Errorf("unsupported commands reached the vehicle: %+v", cmds)
+	}
+}
+
+func TestWindows(t *testing.T) {
+	const id = "00000000-0000-0000-0000-0000000000f4"
+	srv, v := newControlVehicle(t, "1234", bluelinktest.Vehicle{ID: id, VIN: "VIN-4", Type: "GN", Features: []string{"REMOTE_WINDOW"}})
+	if err := v.VentWindows(); err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := srv.State(id); !s.Windows.FrontLeft || !s.Windows.FrontRight || !s.Windows.BackLeft || !s.Windows.BackRight {
+		t.Errorf("got windows %+v after venting", s.Windows)
+	}
+	if err := v.CloseWindows(); err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := srv.State(id); s.Windows != (goblue.Windows{}) {
+		t.Errorf("got windows %+v after closing", s.Windows)
+	}
+
+	cmds := srv.Commands()
+	if len(cmds) != 2 {
+		t.Fatalf("got commands %+v, want two", cmds)
+	}
+	for i, action := range []string{"vent", "close"} {
+		if c := cmds[i]; c.Name != "window" || c.Payload["action"] != action || c.Payload["deviceId"] == nil {
+			t.Errorf("command %d: got %+v, want window %s", i, c, action)
+		}
+	}
+
+	// ccs2 vehicles support window control without the feature
+	srv, v = newControlVehicle(t, "1234", bluelinktest.Vehicle{ID: id, VIN: "VIN-4", Type: "EV", CCS2: true})
+	if err := v.CloseWindows(); err != nil {
+		t.Fatal(err)
+	}
+	want := []bluelinktest.Command{{VehicleID: id, Name: "window", Payload: map[string]interface{}{"command": "close"}}}
+	if got := srv.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got commands %+v, want %+v", got, want)
+	}
+
+	srv, v = newControlVehicle(t, "1234", bluelinktest.Vehicle{ID: id, VIN: "VIN-4", Type: "GN"})
+	for name, send := range map[string]func() error{"CloseWindows": v.CloseWindows, "VentWindows": v.VentWindows} {
+		if err := send(); !errors.Is(err, goblue.ErrUnsupported) {
+			t.Errorf("%s: got %v, want ErrUnsupported", name, err)
+		}
+	}
+	if cmds := srv.Commands(); len(cmds) != 0 {
+		t.Errorf("unsupported commands reached the vehicle: %+v", cmds)
+	}
+}
+
+func TestChargeRequiresCharging(t *testing.T) {
+	const id = "00000000-0000-0000-0000-0000000000f5"
+	srv, v := newControlVehicle(t, "1234", bluelinktest.Vehicle{ID: id, VIN: "VIN-5", Type: "GN"})
+	for name, send := range map[string]func() error{"StartCharge": v.StartCharge, "StopCharge": v.StopCharge} {
+		err := send()
+		if !errors.Is(err, goblue.ErrUnsupported) || !strings.Contains(err.Error(), "charging") {
+			t.Errorf("%s: got %v, want ErrUnsupported requiring charging", name, err)
+		}
+	}
+	if cmds := srv.Commands(); len(cmds) != 0 {
+		t.Errorf("unsupported commands reached the vehicle: %+v", cmds)
+	}
+
+	srv, v = newControlVehicle(t, "1234", bluelinktest.Vehicle{ID: id, VIN: "VIN-5", Type: "PHEV"})
+	if err := v.StartCharge(); err != nil {
+		t.Fatal(err)
+	}
+	if cmds := srv.Commands(); len(cmds) != 1 || cmds[0].Name != "charge" || cmds[0].Payload["action"] != "start" {
+		t.Errorf("got commands %+v, want charge start", cmds)
+	}
+}
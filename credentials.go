@@ -0,0 +1,238 @@
+package goblue
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Credentials are the secrets of a Bluelink account.
+type Credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Pin      string `json:"pin"`
+}
+
+// CredentialSource provides the credentials of an account. It is asked on
+// every authentication, so rotated secrets are picked up without creating a
+// new Client.
+type CredentialSource interface {
+	Credentials() (Credentials, error)
+}
+
+// CredentialSourceFunc is an adapter to use ordinary functions as
+// CredentialSource.
+type CredentialSourceFunc func() (Credentials, error)
+
+func (f CredentialSourceFunc) Credentials() (Credentials, error) { return f() }
+
+// EnvCredentials reads the credentials from the environment variables
+// <prefix>_USERNAME, <prefix>_PASSWORD and <prefix>_PIN.
+func EnvCredentials(prefix string) CredentialSource {
+	return CredentialSourceFunc(func() (Credentials, error) {
+		return Credentials{
+			Username: os.Getenv(prefix + "_USERNAME"),
+			Password: os.Getenv(prefix + "_PASSWORD"),
+			Pin:      os.Getenv(prefix + "_PIN"),
+		}, nil
+	})
+}
+
+// CommandCredentials runs a password manager command like `pass show kia`.
+// The first line of its output is the password, following lines of the form
+// "username: ..." and "pin: ..." provide the other credentials.
+func CommandCredentials(name string, args ...string) CredentialSource {
+	return CredentialSourceFunc(func() (Credentials, error) {
+		cmd := exec.Command(name, args...)
+		cmd.Stderr = os.Stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return Credentials{}, fmt.Errorf("credential command %s: %w", name, err)
+		}
+		return parseCommandCredentials(out), nil
+	})
+}
+
+func parseCommandCredentials(out []byte) Credentials {
+	var c Credentials
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	if scanner.Scan() {
+		c.Password = strings.TrimSpace(scanner.Text())
+	}
+	for scanner.Scan() {
+		kv := strings.SplitN(scanner.Text(), ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		value := strings.TrimSpace(kv[1])
+		switch strings.ToLower(strings.TrimSpace(kv[0])) {
+		case "username", "user", "login", "email":
+			c.Username = value
+		case "pin":
+			c.Pin = value
+		}
+	}
+	return c
+}
+
+const (
+	credentialFileVersion = 1
+	credentialKDF         = "pbkdf2-sha256"
+	credentialIterations  = 600000
+	credentialSaltSize    = 16
+	credentialKeySize     = 32
+)
+
+// credentialFile is the on-disk format of an encrypted credentials file.
+type credentialFile struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// FileCredentials reads the credentials from a file written by
+// WriteCredentialsFile. The passphrase is requested on the first read only,
+// the key derived from it is kept for later reads until the file is written
+// with a new salt.
+func FileCredentials(path string, passphrase func() ([]byte, error)) CredentialSource {
+	s := &fileCredentials{path: path, passphrase: passphrase}
+	return CredentialSourceFunc(s.credentials)
+}
+
+type fileCredentials struct {
+	path       string
+	passphrase func() ([]byte, error)
+
+	mu         sync.Mutex // guards the cached key
+	salt       []byte
+	iterations int
+	key        []byte
+}
+
+func (s *fileCredentials) credentials() (Credentials, error) {
+	raw, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		return Credentials{}, err
+	}
+	f, err := parseCredentialFile(raw)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.key != nil && bytes.Equal(s.salt, f.Salt) && s.iterations == f.Iterations {
+		return f.decrypt(s.key)
+	}
+
+	pass, err := s.passphrase()
+	if err != nil {
+		return Credentials{}, err
+	}
+	key := credentialKey(pass, f.Salt, f.Iterations)
+	c, err := f.decrypt(key)
+	if err != nil {
+		return Credentials{}, err
+	}
+	s.salt, s.iterations, s.key = f.Salt, f.Iterations, key
+	return c, nil
+}
+
+// WriteCredentialsFile encrypts the credentials with a key derived from the
+// passphrase and writes them to path, readable by the current user only.
+func WriteCredentialsFile(path string, c Credentials, passphrase []byte) error {
+	raw, err := encryptCredentials(c, passphrase)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, raw, 0600)
+}
+
+func encryptCredentials(c Credentials, passphrase []byte) ([]byte, error) {
+	plaintext, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+
+	f := credentialFile{
+		Version:    credentialFileVersion,
+		KDF:        credentialKDF,
+		Iterations: credentialIterations,
+		Salt:       make([]byte, credentialSaltSize),
+	}
+	if _, err := rand.Read(f.Salt); err != nil {
+		return nil, err
+	}
+	aead, err := credentialCipher(credentialKey(passphrase, f.Salt, f.Iterations))
+	if err != nil {
+		return nil, err
+	}
+	f.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(f.Nonce); err != nil {
+		return nil, err
+	}
+	f.Ciphertext = aead.Seal(nil, f.Nonce, plaintext, nil)
+
+	return json.MarshalIndent(f, "", "  ")
+}
+
+func parseCredentialFile(raw []byte) (*credentialFile, error) {
+	var f credentialFile
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return nil, err
+	}
+	if f.Version != credentialFileVersion || f.KDF != credentialKDF {
+		return nil, fmt.Errorf("unsupported credentials file version %d (%s)", f.Version, f.KDF)
+	}
+	return &f, nil
+}
+
+// decrypt opens the credentials with the key derived from the passphrase.
+func (f *credentialFile) decrypt(key []byte) (Credentials, error) {
+	aead, err := credentialCipher(key)
+	if err != nil {
+		return Credentials{}, err
+	}
+	if len(f.Nonce) != aead.NonceSize() {
+		return Credentials{}, ErrInvalidPassphrase
+	}
+	plaintext, err := aead.Open(nil, f.Nonce, f.Ciphertext, nil)
+	if err != nil {
+		return Credentials{}, ErrInvalidPassphrase
+	}
+
+	var c Credentials
+	if err := json.Unmarshal(plaintext, &c); err != nil {
+		return Credentials{}, err
+	}
+	return c, nil
+}
+
+// credentialKey derives the file key from the passphrase with
+// PBKDF2-HMAC-SHA256.
+func credentialKey(passphrase, salt []byte, iterations int) []byte {
+	return pbkdf2.Key(passphrase, salt, iterations, credentialKeySize, sha256.New)
+}
+
+func credentialCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,66 @@
+package goblue
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileCredentials(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	want := Credentials{Username: "user@example.com", Password: "secret", Pin: "1234"}
+	if err := WriteCredentialsFile(path, want, []byte("correct horse")); err != nil {
+		t.Fatal(err)
+	}
+
+	var prompts int
+	passphrase := func(p string) func() ([]byte, error) {
+		return func() ([]byte, error) {
+			prompts++
+			return []byte(p), nil
+		}
+	}
+
+	src := FileCredentials(path, passphrase("correct horse"))
+	for i := 0; i < 2; i++ {
+		got, err := src.Credentials()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("read %+v, want %+v", got, want)
+		}
+	}
+	if prompts != 1 {
+		t.Errorf("passphrase requested %d times, want once", prompts)
+	}
+
+	// a rewritten file has a new salt, the cached key no longer fits
+	if err := WriteCredentialsFile(path, want, []byte("correct horse")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := src.Credentials(); err != nil {
+		t.Fatal(err)
+	}
+	if prompts != 2 {
+		t.Errorf("passphrase requested %d times after rewrite, want twice", prompts)
+	}
+
+	src = FileCredentials(path, passphrase("wrong"))
+	for i := 0; i < 2; i++ {
+		if _, err := src.Credentials(); !errors.Is(err, ErrInvalidPassphrase) {
+			t.Errorf("wrong passphrase: got %v, want ErrInvalidPassphrase", err)
+		}
+	}
+	if prompts != 4 {
+		t.Errorf("a wrong passphrase must not be cached, requested %d times", prompts)
+	}
+}
+
+func TestParseCommandCredentials(t *testing.T) {
+	got := parseCommandCredentials([]byte("secret\nusername: user@example.com\nPIN: 1234\nurl: example.com\n"))
+	want := Credentials{Username: "user@example.com", Password: "secret", Pin: "1234"}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
@@ -7,6 +7,24 @@ type endpoints struct {
 	AccessToken string
 	Vehicles    string
 	Status      string
+	CCS2Status  string
+	TripInfo    string
+	DrvHistory  string
+	Report      string
+	Pin         string
+	HornLight   string
+	Light       string
+	Records     string
+	Window      string
+	CCS2Window  string
+	Door        string
+	CCS2Door    string
+	Climate     string
+	CCS2Climate string
+	Charge      string
+	CCS2Charge  string
+	Location    string
+	LatestState string
 }
 
 func defaultEndpoints() endpoints {
@@ -17,5 +35,23 @@ func defaultEndpoints() endpoints {
 		AccessToken: "/api/v1/user/oauth2/token",
 		Vehicles:    "/api/v1/spa/vehicles",
 		Status:      "/api/v1/spa/vehicles/%s/status",
+		CCS2Status:  "/api/v1/spa/vehicles/%s/ccs2/carstatus/latest",
+		TripInfo:    "/api/v1/spa/vehicles/%s/tripinfo",
+		DrvHistory:  "/api/v1/spa/vehicles/%s/drvhistory",
+		Report:      "/api/v1/spa/vehicles/%s/monthlyreport",
+		Pin:         "/api/v1/user/pin",
+		HornLight:   "/api/v2/spa/vehicles/%s/ccs2/control/hornlight",
+		Light:       "/api/v2/spa/vehicles/%s/ccs2/control/light",
+		Records:     "/api/v1/spa/notifications/%s/records",
+		Window:      "/api/v2/spa/vehicles/%s/control/window",
+		CCS2Window:  "/api/v2/spa/vehicles/%s/ccs2/control/window",
+		Door:        "/api/v2/spa/vehicles/%s/control/door",
+		CCS2Door:    "/api/v2/spa/vehicles/%s/ccs2/control/door",
+		Climate:     "/api/v2/spa/vehicles/%s/control/temperature",
+		CCS2Climate: "/api/v2/spa/vehicles/%s/ccs2/control/temperature",
+		Charge:      "/api/v2/spa/vehicles/%s/control/charge",
+		CCS2Charge:  "/api/v2/spa/vehicles/%s/ccs2/control/charge",
+		Location:    "/api/v1/spa/vehicles/%s/location/park",
+		LatestState: "/api/v1/spa/vehicles/%s/status/latest",
 	}
 }
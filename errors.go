@@ -1,6 +1,11 @@
 package goblue
 
-import "errors"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
 
 var (
 	ErrNotImplemented       = errors.New("function not implemented")
@@ -8,4 +13,100 @@ var (
 	ErrAuthenticationFailed = errors.New("client authentication failed")
 	ErrUnknownBrand         = errors.New("unknown brand")
 	ErrNoVehicleFound       = errors.New("no vehicle found")
+	ErrCommandFailed        = errors.New("vehicle command failed")
+	ErrCommandTimeout       = errors.New("vehicle command timed out")
+	ErrUnsupported          = errors.New("not supported by vehicle")
+	ErrRateLimited          = errors.New("request limit exceeded")
+	ErrInvalidPIN           = errors.New("invalid pin")
+	ErrVehicleAsleep        = errors.New("vehicle not responding")
+	ErrInvalidPassphrase    = errors.New("invalid passphrase or corrupted credentials")
+	ErrDuplicateAccount     = errors.New("account already registered")
+	ErrInvalidTemperature   = errors.New("temperature out of range")
+	ErrAlreadyRunning       = errors.New("already running")
 )
+
+// resCodeErrors maps the resCode of failed api calls to sentinel errors.
+var resCodeErrors = map[string]error{
+	"4002": ErrNotAuthenticated, // invalid device id
+	"4003": ErrInvalidPIN,
+	"4081": ErrVehicleAsleep,
+	"5091": ErrRateLimited,
+	"5921": ErrUnsupported, // no data for vehicle
+	"9999": ErrVehicleAsleep,
+}
+
+// statusCodeErrors maps http status codes to sentinel errors.
+var statusCodeErrors = map[int]error{
+	http.StatusUnauthorized:    ErrNotAuthenticated,
+	http.StatusForbidden:       ErrNotAuthenticated,
+	http.StatusTooManyRequests: ErrRateLimited,
+	http.StatusNotImplemented:  ErrUnsupported,
+}
+
+// APIError describes a request rejected by the api. Use errors.Is to check
+// it against the sentinel errors of this package.
+type APIError struct {
+	StatusCode int
+	RetCode    string
+	ResCode    string
+	Message    string
+	MsgID      string
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("api error: http status %d", e.StatusCode)
+	if e.ResCode != "" {
+		msg += ", code " + e.ResCode
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+// Is reports whether the error corresponds to the given sentinel error.
+func (e *APIError) Is(target error) bool {
+	if err, ok := resCodeErrors[e.ResCode]; ok && err == target {
+		return true
+	}
+	if err, ok := statusCodeErrors[e.StatusCode]; ok && err == target {
+		return true
+	}
+	return false
+}
+
+// checkResponse returns an *APIError unless the request succeeded with a
+// positive retCode.
+func checkResponse(statusCode int, body []byte) error {
+	msg := struct {
+		Retcode string          `json:"retCode"`
+		Rescode string          `json:"resCode"`
+		Resmsg  json.RawMessage `json:"resMsg"`
+		Msgid   string          `json:"msgId"`
+		// the user endpoints report failures without the envelope
+		Errcode string `json:"errCode"`
+		Errmsg  string `json:"errMsg"`
+	}{}
+	// error bodies are not guaranteed to be json, keep the status code anyway
+	_ = json.Unmarshal(body, &msg)
+
+	if statusCode >= http.StatusOK && statusCode < http.StatusMultipleChoices &&
+		msg.Retcode == apiCodeOk {
+		return nil
+	}
+
+	e := &APIError{
+		StatusCode: statusCode,
+		RetCode:    msg.Retcode,
+		ResCode:    msg.Rescode,
+		MsgID:      msg.Msgid,
+	}
+	var text string
+	if err := json.Unmarshal(msg.Resmsg, &text); err == nil {
+		e.Message = text
+	}
+	if e.ResCode == "" {
+		e.ResCode, e.Message = msg.Errcode, msg.Errmsg
+	}
+	return e
+}
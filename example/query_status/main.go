@@ -4,10 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"log"
-	"net/http"
-	"net/http/httputil"
 	"os"
-	"strings"
 	"time"
 
 	"github.com/frzifus/goblue"
@@ -39,12 +36,7 @@ func main() {
 
 	bl, err := goblue.NewClient(
 		cfg,
-		goblue.WithTransport(
-			newTripper(
-				log.New(os.Stdout, "bluelink-api:", 0),
-				http.DefaultTransport,
-			),
-		),
+		goblue.WithLogger(goblue.NewLogger(log.New(os.Stdout, "bluelink-api:", 0), true)),
 		goblue.WithTimeout(2*time.Minute),
 	)
 	if err != nil {
@@ -64,49 +56,3 @@ func main() {
 		logger.Println(v.Status())
 	}
 }
-
-type logger interface {
-	Println(v ...interface{})
-}
-
-type roundTripper struct {
-	logger    logger
-	transport http.RoundTripper
-}
-
-const max = 2048
-
-// newTripper creates a logging roundtrip handler
-// inspired by: github.com/andig/evcc/util/request/roundtrip.go
-func newTripper(logger logger, transport http.RoundTripper) http.RoundTripper {
-	tripper := &roundTripper{
-		logger:    logger,
-		transport: transport,
-	}
-
-	return tripper
-}
-
-func (r *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
-	if body, err := httputil.DumpRequest(req, true); err == nil {
-		s := strings.TrimSpace(string(body))
-		if len(s) > max {
-			s = s[:max]
-		}
-		r.logger.Println(s)
-	}
-
-	resp, err := r.transport.RoundTrip(req)
-
-	if resp != nil {
-		if body, err := httputil.DumpResponse(resp, true); err == nil {
-			s := strings.TrimSpace(string(body))
-			if len(s) > max {
-				s = s[:max]
-			}
-			r.logger.Println(s)
-		}
-	}
-
-	return resp, err
-}
@@ -3,6 +3,8 @@ module github.com/frzifus/goblue
 go 1.16
 
 require (
+	github.com/eclipse/paho.mqtt.golang v1.3.5
 	github.com/google/uuid v1.2.0
-	golang.org/x/net v0.0.0-20210405180319-a5a99cb37ef4 // indirect
+	golang.org/x/crypto v0.11.0
+	golang.org/x/net v0.12.0
 )
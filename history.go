@@ -0,0 +1,115 @@
+package goblue
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	drivingPeriodTotal = 0
+	drivingPeriodDay   = 1
+)
+
+// EnergyConsumption breaks down the energy used while driving. All values
+// are reported in Wh.
+type EnergyConsumption struct {
+	Total       int
+	Drivetrain  int
+	Climate     int
+	Electronics int
+	BatteryCare int
+	Regenerated int
+	Distance    int // km
+}
+
+// DailyConsumption is the energy consumption of a single day.
+type DailyConsumption struct {
+	Date time.Time
+	EnergyConsumption
+}
+
+// DriveHistory contains the consumption of the last 30 days, in total and
+// per day.
+type DriveHistory struct {
+	Last30Days EnergyConsumption
+	Days       []DailyConsumption
+}
+
+type energyConsumptionResponse struct {
+	Totalpwrcsp     int `json:"totalPwrCsp"`
+	Motorpwrcsp     int `json:"motorPwrCsp"`
+	Climatepwrcsp   int `json:"climatePwrCsp"`
+	Edpwrcsp        int `json:"eDPwrCsp"`
+	Batterymgpwrcsp int `json:"batteryMgPwrCsp"`
+	Regenpwr        int `json:"regenPwr"`
+	Calculativeodo  int `json:"calculativeOdo"`
+}
+
+func (e energyConsumptionResponse) consumption() EnergyConsumption {
+	return EnergyConsumption{
+		Total:       e.Totalpwrcsp,
+		Drivetrain:  e.Motorpwrcsp,
+		Climate:     e.Climatepwrcsp,
+		Electronics: e.Edpwrcsp,
+		BatteryCare: e.Batterymgpwrcsp,
+		Regenerated: e.Regenpwr,
+		Distance:    e.Calculativeodo,
+	}
+}
+
+// DriveHistory returns the energy consumption statistics of the vehicle.
+func (v *Vehicle) DriveHistory() (_ *DriveHistory, err error) {
+	return v.DriveHistoryContext(context.Background())
+}
+
+// DriveHistoryContext is like DriveHistory, using ctx for the requests and the
+// parent span.
+func (v *Vehicle) DriveHistoryContext(ctx context.Context) (_ *DriveHistory, err error) {
+	ctx, op := v.startOperation(ctx, "goblue.Vehicle.DriveHistory")
+	defer op.end(&err)
+
+	return v.driveHistory(ctx)
+}
+
+func (v *Vehicle) driveHistory(ctx context.Context) (*DriveHistory, error) {
+	payload := map[string]interface{}{
+		"periodTarget": drivingPeriodDay,
+	}
+
+	msg := struct {
+		Drivinginfo []struct {
+			energyConsumptionResponse
+			Drivingperiod int `json:"drivingPeriod"`
+		} `json:"drivingInfo"`
+		Drivinginfodetail []struct {
+			energyConsumptionResponse
+			Drivingdate string `json:"drivingDate"`
+		} `json:"drivingInfoDetail"`
+	}{}
+
+	uri := fmt.Sprintf(v.auth.URI+v.endpoints.DrvHistory, v.id)
+	if err := v.doSpaRequest(ctx, http.MethodPost, uri, payload, &msg); err != nil {
+		return nil, err
+	}
+
+	history := &DriveHistory{}
+	for _, i := range msg.Drivinginfo {
+		if i.Drivingperiod == drivingPeriodTotal {
+			history.Last30Days = i.consumption()
+		}
+	}
+	for _, d := range msg.Drivinginfodetail {
+		date, err := time.ParseInLocation(tripDayLayout, d.Drivingdate, apiLocation)
+		if err != nil {
+			return nil, err
+		}
+		history.Days = append(history.Days, DailyConsumption{
+			Date:              date,
+			EnergyConsumption: d.consumption(),
+		})
+	}
+
+	return history, nil
+}
@@ -0,0 +1,51 @@
+package goblue
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDriveHistory(t *testing.T) {
+	body, err := os.ReadFile(filepath.Join("testdata", "history", "kia-eu-ev.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := newFixtureVehicle(t, "EV", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v1/spa/vehicles/v1/drvhistory" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		var payload map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Error(err)
+		}
+		if payload["periodTarget"] != float64(drivingPeriodDay) {
+			t.Errorf("got payload %v", payload)
+		}
+		w.Write(body)
+	})
+
+	history, err := v.DriveHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &DriveHistory{
+		Last30Days: EnergyConsumption{Total: 214380, Drivetrain: 181223, Climate: 21876, Electronics: 7702,
+			BatteryCare: 3579, Regenerated: 39810, Distance: 1371},
+		Days: []DailyConsumption{
+			{Date: time.Date(2024, 3, 1, 0, 0, 0, 0, apiLocation), EnergyConsumption: EnergyConsumption{Total: 8213,
+				Drivetrain: 6870, Climate: 912, Electronics: 301, BatteryCare: 130, Regenerated: 1544, Distance: 52}},
+			{Date: time.Date(2024, 3, 2, 0, 0, 0, 0, apiLocation), EnergyConsumption: EnergyConsumption{Total: 2051,
+				Drivetrain: 1730, Climate: 203, Electronics: 88, BatteryCare: 30, Regenerated: 402, Distance: 14}},
+		},
+	}
+	if !reflect.DeepEqual(history, want) {
+		t.Errorf("got %+v\nwant %+v", history, want)
+	}
+}
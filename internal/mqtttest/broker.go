@@ -0,0 +1,504 @@
+// Package mqtttest provides a minimal in-process MQTT 3.1.1 broker to test
+// the mqtt bridge without an external broker.
+//
+//	broker, err := mqtttest.NewBroker()
+//	defer broker.Close()
+//
+//	opts := paho.NewClientOptions().AddBroker(broker.URL())
+//
+// The broker accepts publishes of all QoS levels, retained messages,
+// wildcard subscriptions and last will messages. Messages are delivered to
+// subscribers with QoS 0.
+package mqtttest
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+)
+
+// packet types of MQTT 3.1.1
+const (
+	packetConnect     = 1
+	packetConnack     = 2
+	packetPublish     = 3
+	packetPuback      = 4
+	packetPubrec      = 5
+	packetPubrel      = 6
+	packetPubcomp     = 7
+	packetSubscribe   = 8
+	packetSuback      = 9
+	packetUnsubscribe = 10
+	packetUnsuback    = 11
+	packetPingreq     = 12
+	packetPingresp    = 13
+	packetDisconnect  = 14
+)
+
+// Message is a message published to the Broker.
+type Message struct {
+	Topic    string
+	Payload  []byte
+	Retained bool
+}
+
+// Broker is an MQTT broker listening on a random local port. All methods
+// are safe for concurrent use.
+type Broker struct {
+	listener net.Listener
+
+	mu       sync.Mutex
+	sessions map[*session]struct{}
+	retained map[string][]byte
+	messages []Message
+	watchers []*watcher
+	wg       sync.WaitGroup
+}
+
+type watcher struct {
+	filter string
+	ch     chan Message
+}
+
+// NewBroker starts a Broker. It has to be closed after use.
+func NewBroker() (*Broker, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	b := &Broker{
+		listener: l,
+		sessions: map[*session]struct{}{},
+		retained: map[string][]byte{},
+	}
+	b.wg.Add(1)
+	go b.serve()
+	return b, nil
+}
+
+// URL returns the address clients connect to.
+func (b *Broker) URL() string {
+	return "tcp://" + b.listener.Addr().String()
+}
+
+// Close disconnects all clients and stops the broker.
+func (b *Broker) Close() error {
+	err := b.listener.Close()
+	b.mu.Lock()
+	for s := range b.sessions {
+		s.conn.Close()
+	}
+	for _, w := range b.watchers {
+		close(w.ch)
+	}
+	b.watchers = nil
+	b.mu.Unlock()
+	b.wg.Wait()
+	return err
+}
+
+// Publish delivers a message to all subscribers as if a client published
+// it.
+func (b *Broker) Publish(topic string, payload []byte, retain bool) {
+	b.route(Message{Topic: topic, Payload: payload, Retained: retain})
+}
+
+// Retained returns the retained message of a topic.
+func (b *Broker) Retained(topic string) ([]byte, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	p, ok := b.retained[topic]
+	return p, ok
+}
+
+// Messages returns all messages published so far whose topic matches the
+// filter, which may contain the wildcards + and #.
+func (b *Broker) Messages(filter string) []Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var msgs []Message
+	for _, m := range b.messages {
+		if match(filter, m.Topic) {
+			msgs = append(msgs, m)
+		}
+	}
+	return msgs
+}
+
+// Watch returns a channel receiving all messages published from now on
+// whose topic matches the filter. The channel is closed with the broker.
+func (b *Broker) Watch(filter string) <-chan Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	w := &watcher{filter: filter, ch: make(chan Message, 64)}
+	b.watchers = append(b.watchers, w)
+	return w.ch
+}
+
+func (b *Broker) serve() {
+	defer b.wg.Done()
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+		s := &session{broker: b, conn: conn, subscriptions: map[string]struct{}{}}
+		b.mu.Lock()
+		b.sessions[s] = struct{}{}
+		b.mu.Unlock()
+
+		b.wg.Add(1)
+		go func() {
+			defer b.wg.Done()
+			s.serve()
+		}()
+	}
+}
+
+// route stores and delivers a published message.
+func (b *Broker) route(m Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.messages = append(b.messages, m)
+	if m.Retained {
+		if len(m.Payload) == 0 {
+			delete(b.retained, m.Topic)
+		} else {
+			b.retained[m.Topic] = m.Payload
+		}
+	}
+	for s := range b.sessions {
+		if s.subscribed(m.Topic) {
+			// retain is only set on messages sent on subscription
+			s.publish(m.Topic, m.Payload, false)
+		}
+	}
+	for _, w := range b.watchers {
+		if match(w.filter, m.Topic) {
+			select {
+			case w.ch <- m:
+			default:
+			}
+		}
+	}
+}
+
+// session is the connection of a single client.
+type session struct {
+	broker *Broker
+	conn   net.Conn
+
+	writeMu       sync.Mutex
+	mu            sync.Mutex
+	subscriptions map[string]struct{}
+	will          *Message
+}
+
+func (s *session) serve() {
+	defer func() {
+		s.conn.Close()
+		s.broker.mu.Lock()
+		delete(s.broker.sessions, s)
+		s.broker.mu.Unlock()
+
+		s.mu.Lock()
+		will := s.will
+		s.mu.Unlock()
+		if will != nil {
+			s.broker.route(*will)
+		}
+	}()
+
+	r := bufio.NewReader(s.conn)
+	for {
+		header, body, err := readPacket(r)
+		if err != nil {
+			return
+		}
+		if err := s.handle(header, body); err != nil {
+			return
+		}
+	}
+}
+
+// errDisconnect ends a session without publishing its will.
+var errDisconnect = errors.New("disconnect")
+
+func (s *session) handle(header byte, body []byte) error {
+	switch header >> 4 {
+	case packetConnect:
+		if err := s.connect(body); err != nil {
+			return err
+		}
+		return s.write(packetConnack<<4, []byte{0, 0})
+	case packetPublish:
+		return s.received(header, body)
+	case packetPubrel:
+		d := decoder{buf: body}
+		id := d.bytes(2)
+		if d.err != nil {
+			return d.err
+		}
+		return s.write(packetPubcomp<<4, id)
+	case packetSubscribe:
+		return s.subscribe(body)
+	case packetUnsubscribe:
+		return s.unsubscribe(body)
+	case packetPingreq:
+		return s.write(packetPingresp<<4, nil)
+	case packetDisconnect:
+		s.mu.Lock()
+		s.will = nil
+		s.mu.Unlock()
+		return errDisconnect
+	case packetPuback, packetPubrec, packetPubcomp:
+		return nil
+	}
+	return fmt.Errorf("unsupported packet type %d", header>>4)
+}
+
+func (s *session) connect(body []byte) error {
+	d := decoder{buf: body}
+	d.string() // protocol name
+	d.byte()   // protocol level
+	flags := d.byte()
+	d.uint16() // keep alive
+	d.string() // client id
+
+	if flags&0x04 != 0 {
+		will := &Message{
+			Topic:    d.string(),
+			Payload:  []byte(d.string()),
+			Retained: flags&0x20 != 0,
+		}
+		s.mu.Lock()
+		s.will = will
+		s.mu.Unlock()
+	}
+	return d.err
+}
+
+func (s *session) received(header byte, body []byte) error {
+	d := decoder{buf: body}
+	topic := d.string()
+	qos := (header >> 1) & 0x03
+	var id []byte
+	if qos > 0 {
+		id = d.bytes(2)
+	}
+	if d.err != nil {
+		return d.err
+	}
+
+	s.broker.route(Message{Topic: topic, Payload: append([]byte(nil), d.rest()...), Retained: header&0x01 != 0})
+
+	switch qos {
+	case 1:
+		return s.write(packetPuback<<4, id)
+	case 2:
+		return s.write(packetPubrec<<4, id)
+	}
+	return nil
+}
+
+func (s *session) subscribe(body []byte) error {
+	d := decoder{buf: body}
+	id := d.bytes(2)
+	var filters []string
+	for d.err == nil && len(d.buf) > 0 {
+		filters = append(filters, d.string())
+		d.byte() // requested qos
+	}
+	if d.err != nil {
+		return d.err
+	}
+
+	s.mu.Lock()
+	for _, f := range filters {
+		s.subscriptions[f] = struct{}{}
+	}
+	s.mu.Unlock()
+
+	granted := make([]byte, len(filters))
+	if err := s.write(packetSuback<<4, append(id, granted...)); err != nil {
+		return err
+	}
+
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	for topic, payload := range s.broker.retained {
+		for _, f := range filters {
+			if match(f, topic) {
+				s.publish(topic, payload, true)
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func (s *session) unsubscribe(body []byte) error {
+	d := decoder{buf: body}
+	id := d.bytes(2)
+	s.mu.Lock()
+	for d.err == nil && len(d.buf) > 0 {
+		delete(s.subscriptions, d.string())
+	}
+	s.mu.Unlock()
+	if d.err != nil {
+		return d.err
+	}
+	return s.write(packetUnsuback<<4, id)
+}
+
+func (s *session) subscribed(topic string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for f := range s.subscriptions {
+		if match(f, topic) {
+			return true
+		}
+	}
+	return false
+}
+
+// publish sends a message with QoS 0. Write errors close the connection
+// and are handled by the reading side.
+func (s *session) publish(topic string, payload []byte, retain bool) {
+	header := byte(packetPublish << 4)
+	if retain {
+		header |= 0x01
+	}
+	body := appendString(nil, topic)
+	body = append(body, payload...)
+	if err := s.write(header, body); err != nil {
+		s.conn.Close()
+	}
+}
+
+func (s *session) write(header byte, body []byte) error {
+	packet := []byte{header}
+	packet = appendLength(packet, len(body))
+	packet = append(packet, body...)
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	_, err := s.conn.Write(packet)
+	return err
+}
+
+func readPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		if i == 4 {
+			return 0, nil, errors.New("malformed remaining length")
+		}
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(b&0x7f) * multiplier
+		if b&0x80 == 0 {
+			break
+		}
+		multiplier *= 128
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return header, body, nil
+}
+
+func appendLength(b []byte, n int) []byte {
+	for {
+		digit := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			digit |= 0x80
+		}
+		b = append(b, digit)
+		if n == 0 {
+			return b
+		}
+	}
+}
+
+func appendString(b []byte, s string) []byte {
+	b = append(b, byte(len(s)>>8), byte(len(s)))
+	return append(b, s...)
+}
+
+// decoder reads the fields of a packet body, the first error sticks.
+type decoder struct {
+	buf []byte
+	err error
+}
+
+func (d *decoder) bytes(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if len(d.buf) < n {
+		d.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	b := d.buf[:n:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+func (d *decoder) byte() byte {
+	if b := d.bytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (d *decoder) uint16() uint16 {
+	if b := d.bytes(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (d *decoder) string() string {
+	return string(d.bytes(int(d.uint16())))
+}
+
+func (d *decoder) rest() []byte {
+	b := d.buf
+	d.buf = nil
+	return b
+}
+
+// match reports whether the topic matches the subscription filter.
+func match(filter, topic string) bool {
+	f := strings.Split(filter, "/")
+	t := strings.Split(topic, "/")
+	for i, level := range f {
+		switch {
+		case level == "#":
+			return true
+		case i >= len(t):
+			return false
+		case level != "+" && level != t[i]:
+			return false
+		}
+	}
+	return len(f) == len(t)
+}
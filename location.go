@@ -0,0 +1,146 @@
+package goblue
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// odometer units as reported by the status endpoint
+const (
+	odometerUnitKm    = 1
+	odometerUnitMiles = 3
+
+	kmPerMile = 1.609344
+)
+
+// Location is the last known position of a parked vehicle.
+type Location struct {
+	Latitude  float64   `json:"latitude"`
+	Longitude float64   `json:"longitude"`
+	Altitude  float64   `json:"altitude"`
+	Heading   float64   `json:"heading"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Location returns the position the vehicle was parked at as
+// "latitude,longitude".
+//
+// Deprecated: Use Position, which also reports altitude, heading and time.
+func (v *Vehicle) Location() (string, error) {
+	loc, err := v.Position()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%f,%f", loc.Latitude, loc.Longitude), nil
+}
+
+// Position returns the position the vehicle was parked at.
+func (v *Vehicle) Position() (_ *Location, err error) {
+	return v.PositionContext(context.Background())
+}
+
+// PositionContext is like Position, using ctx for the requests and the parent
+// span.
+func (v *Vehicle) PositionContext(ctx context.Context) (_ *Location, err error) {
+	ctx, op := v.startOperation(ctx, "goblue.Vehicle.Position")
+	defer op.end(&err)
+
+	msg := struct {
+		Gpsdetail struct {
+			Coord struct {
+				Lat float64 `json:"lat"`
+				Lon float64 `json:"lon"`
+				Alt float64 `json:"alt"`
+			} `json:"coord"`
+			Head float64 `json:"head"`
+			Time string  `json:"time"`
+		} `json:"gpsDetail"`
+	}{}
+
+	uri := fmt.Sprintf(v.auth.URI+v.endpoints.Location, v.id)
+	if err := v.doSpaRequest(ctx, http.MethodGet, uri, nil, &msg); err != nil {
+		return nil, err
+	}
+
+	gps := msg.Gpsdetail
+	loc := &Location{
+		Latitude:  gps.Coord.Lat,
+		Longitude: gps.Coord.Lon,
+		Altitude:  gps.Coord.Alt,
+		Heading:   gps.Head,
+	}
+	if gps.Time != "" {
+		if loc.UpdatedAt, err = time.ParseInLocation(statusTimeLayout, gps.Time, apiLocation); err != nil {
+			return nil, err
+		}
+	}
+	return loc, nil
+}
+
+// Odometer returns the total distance driven by the vehicle in km, formatted
+// with one decimal.
+//
+// Deprecated: Use OdometerKm.
+func (v *Vehicle) Odometer() (string, error) {
+	km, err := v.OdometerKm()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%.1f", km), nil
+}
+
+// OdometerKm returns the total distance driven by the vehicle in km.
+func (v *Vehicle) OdometerKm() (_ float64, err error) {
+	return v.OdometerKmContext(context.Background())
+}
+
+// OdometerKmContext is like OdometerKm, using ctx for the requests and the
+// parent span.
+func (v *Vehicle) OdometerKmContext(ctx context.Context) (_ float64, err error) {
+	ctx, op := v.startOperation(ctx, "goblue.Vehicle.OdometerKm")
+	defer op.end(&err)
+
+	if v.Capabilities().Has(CapabilityCCS2) {
+		return v.ccs2Odometer(ctx)
+	}
+
+	msg := struct {
+		Vehiclestatusinfo struct {
+			Odometer struct {
+				Value float64 `json:"value"`
+				Unit  int     `json:"unit"`
+			} `json:"odometer"`
+		} `json:"vehicleStatusInfo"`
+	}{}
+
+	uri := fmt.Sprintf(v.auth.URI+v.endpoints.LatestState, v.id)
+	if err := v.doSpaRequest(ctx, http.MethodGet, uri, nil, &msg); err != nil {
+		return 0, err
+	}
+
+	odometer := msg.Vehiclestatusinfo.Odometer
+	if odometer.Unit == odometerUnitMiles {
+		return odometer.Value * kmPerMile, nil
+	}
+	return odometer.Value, nil
+}
+
+func (v *Vehicle) ccs2Odometer(ctx context.Context) (float64, error) {
+	msg := struct {
+		State struct {
+			Vehicle struct {
+				Drivetrain struct {
+					Odometer float64 `json:"Odometer"`
+				} `json:"Drivetrain"`
+			} `json:"Vehicle"`
+		} `json:"state"`
+	}{}
+
+	uri := fmt.Sprintf(v.auth.URI+v.endpoints.CCS2Status, v.id)
+	if err := v.doSpaRequest(ctx, http.MethodGet, uri, nil, &msg); err != nil {
+		return 0, err
+	}
+	return msg.State.Vehicle.Drivetrain.Odometer, nil
+}
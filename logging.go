@@ -0,0 +1,230 @@
+package goblue
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const redacted = "REDACTED"
+
+// redactedHeaders are replaced before requests are logged or passed to hooks.
+var redactedHeaders = []string{"Authorization", "Stamp", "Cookie", "Set-Cookie"}
+
+// redactedFields are body fields and query parameters replaced before
+// requests are logged or passed to hooks. Keys are matched case insensitive.
+var redactedFields = map[string]bool{
+	"password":      true,
+	"pin":           true,
+	"code":          true,
+	"controltoken":  true,
+	"access_token":  true,
+	"accesstoken":   true,
+	"refresh_token": true,
+	"refreshtoken":  true,
+}
+
+// Logger is implemented by structured loggers like *slog.Logger. Args are
+// alternating keys and values.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// NewLogger returns a Logger printing messages and their args as plain lines
+// to l. Debug messages are dropped unless debug is set.
+func NewLogger(l *log.Logger, debug bool) Logger {
+	return &lineLogger{logger: l, debug: debug}
+}
+
+type lineLogger struct {
+	logger *log.Logger
+	debug  bool
+}
+
+func (l *lineLogger) Debug(msg string, args ...interface{}) {
+	if !l.debug {
+		return
+	}
+	l.logger.Println(append([]interface{}{msg}, args...)...)
+}
+
+func (l *lineLogger) Error(msg string, args ...interface{}) {
+	l.logger.Println(append([]interface{}{"ERROR", msg}, args...)...)
+}
+
+// Call describes a single api call. Credentials, tokens and stamps are
+// redacted from the headers, the url and both bodies.
+type Call struct {
+	Method       string
+	URL          string
+	Header       http.Header
+	Body         string
+	StatusCode   int
+	ResponseBody string
+	Duration     time.Duration
+	Err          error
+}
+
+// Hook is invoked for every api call.
+type Hook func(*Call)
+
+// WithLogger logs every api call at debug level, failed calls at error level.
+func WithLogger(l Logger) ClientOptions {
+	return WithAfterHook(func(c *Call) {
+		args := []interface{}{
+			"method", c.Method,
+			"url", c.URL,
+			"status", c.StatusCode,
+			"duration", c.Duration,
+			"request", c.Body,
+			"response", c.ResponseBody,
+		}
+		if c.Err != nil {
+			l.Error("api call failed", append(args, "error", c.Err)...)
+			return
+		}
+		l.Debug("api call", args...)
+	})
+}
+
+// WithBeforeHook registers a hook invoked before a call is sent. Only the
+// request fields of the call are set.
+func WithBeforeHook(h Hook) ClientOptions {
+	return WithMiddleware(hookMiddleware(h, nil))
+}
+
+// WithAfterHook registers a hook invoked once a call completed.
+func WithAfterHook(h Hook) ClientOptions {
+	return WithMiddleware(hookMiddleware(nil, h))
+}
+
+func hookMiddleware(before, after Hook) Middleware {
+	return func(next HttpClient) HttpClient {
+		return HttpClientFunc(func(req *http.Request) (*http.Response, error) {
+			reqBody, err := peekBody(&req.Body)
+			if err != nil {
+				return nil, err
+			}
+			call := &Call{
+				Method: req.Method,
+				URL:    redactURL(req.URL),
+				Header: redactHeader(req.Header),
+				Body:   redactBody(req.Header.Get("Content-Type"), reqBody),
+			}
+			if before != nil {
+				before(call)
+			}
+
+			start := time.Now()
+			resp, err := next.Do(req)
+			call.Duration = time.Since(start)
+			call.Err = err
+			if resp != nil {
+				call.StatusCode = resp.StatusCode
+				respBody, err := peekBody(&resp.Body)
+				if err != nil {
+					return nil, err
+				}
+				call.ResponseBody = redactBody(resp.Header.Get("Content-Type"), respBody)
+			}
+			if after != nil {
+				after(call)
+			}
+
+			return resp, err
+		})
+	}
+}
+
+// peekBody reads the body and replaces it by an unread copy.
+func peekBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+	data, err := io.ReadAll(*body)
+	(*body).Close()
+	if err != nil {
+		return nil, err
+	}
+	*body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+func redactHeader(h http.Header) http.Header {
+	h = h.Clone()
+	for _, k := range redactedHeaders {
+		if h.Get(k) != "" {
+			h.Set(k, redacted)
+		}
+	}
+	return h
+}
+
+func redactURL(u *url.URL) string {
+	query := u.Query()
+	for k := range query {
+		if redactedFields[strings.ToLower(k)] {
+			query.Set(k, redacted)
+		}
+	}
+	cp := *u
+	cp.RawQuery = query.Encode()
+	return cp.String()
+}
+
+func redactBody(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		form, err := url.ParseQuery(string(body))
+		if err == nil {
+			for k := range form {
+				if redactedFields[strings.ToLower(k)] {
+					form.Set(k, redacted)
+				}
+			}
+			return form.Encode()
+		}
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return string(body)
+	}
+	out, err := json.Marshal(redactJSON(v))
+	if err != nil {
+		return string(body)
+	}
+	return string(out)
+}
+
+func redactJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, val := range v {
+			if redactedFields[strings.ToLower(k)] {
+				v[k] = redacted
+				continue
+			}
+			v[k] = redactJSON(val)
+		}
+	case []interface{}:
+		for i, val := range v {
+			v[i] = redactJSON(val)
+		}
+	case string:
+		// urls like the login redirect carry the authorization code
+		if u, err := url.Parse(v); err == nil && u.IsAbs() && u.RawQuery != "" {
+			return redactURL(u)
+		}
+	}
+	return v
+}
@@ -0,0 +1,99 @@
+package goblue_test
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/frzifus/goblue"
+	"github.com/frzifus/goblue/bluelinktest"
+)
+
+// stampRecorder records the stamps sent to the api.
+type stampRecorder struct {
+	transport http.RoundTripper
+
+	mu     sync.Mutex
+	stamps []string
+}
+
+func (t *stampRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if stamp := req.Header.Get("Stamp"); stamp != "" {
+		t.mu.Lock()
+		t.stamps = append(t.stamps, stamp)
+		t.mu.Unlock()
+	}
+	return t.transport.RoundTrip(req)
+}
+
+func TestHooksRedactSecrets(t *testing.T) {
+	creds := bluelinktest.Credentials{Username: "user", Password: "pa55-w0rd-xyz", PIN: "9753"}
+	srv := bluelinktest.NewServer(creds)
+	defer srv.Close()
+	srv.AddVehicle(bluelinktest.Vehicle{ID: "00000000-0000-0000-0000-0000000000e1", VIN: "VIN-1", Type: "EV"})
+
+	var (
+		mu    sync.Mutex
+		calls []goblue.Call
+	)
+	record := func(c *goblue.Call) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, *c)
+	}
+	var logged bytes.Buffer
+	stamps := &stampRecorder{transport: srv.Client().Transport}
+	opts := append(srv.ClientOptions(),
+		goblue.WithTransport(stamps),
+		goblue.WithBeforeHook(record),
+		goblue.WithAfterHook(record),
+		goblue.WithLogger(goblue.NewLogger(log.New(&logged, "", 0), true)),
+	)
+	c, err := goblue.NewClient(srv.Config(goblue.BrandKia), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Authenticate(); err != nil {
+		t.Fatal(err)
+	}
+	vs, err := c.Vehicles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := vs[0].Status(); err != nil {
+		t.Fatal(err)
+	}
+	if err := vs[0].Unlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	secrets := []string{creds.Password, creds.PIN, "test-access-token-", "test-control-token-", "test-authorization-code"}
+	if len(stamps.stamps) == 0 {
+		t.Fatal("no stamps sent")
+	}
+	secrets = append(secrets, stamps.stamps...)
+
+	var seen strings.Builder
+	for _, call := range calls {
+		seen.WriteString(call.URL + "\n" + call.Body + "\n" + call.ResponseBody + "\n")
+		for k, vs := range call.Header {
+			seen.WriteString(k + ": " + strings.Join(vs, ",") + "\n")
+		}
+	}
+	for name, out := range map[string]string{"hooks": seen.String(), "logger": logged.String()} {
+		for _, secret := range secrets {
+			if strings.Contains(out, secret) {
+				t.Errorf("%s leaked %q", name, secret)
+			}
+		}
+	}
+	// make sure the calls carrying the secrets were seen at all
+	for _, path := range []string{"/user/signin", "/oauth2/token", "/user/pin", "/control/"} {
+		if !strings.Contains(seen.String(), path) {
+			t.Errorf("no call to %s seen", path)
+		}
+	}
+}
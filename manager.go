@@ -0,0 +1,261 @@
+package goblue
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Manager holds the Clients of several accounts, possibly of different
+// brands and regions, and offers their vehicles as one list keyed by VIN.
+// All methods are safe for concurrent use.
+type Manager struct {
+	mu       sync.RWMutex
+	accounts []*managedAccount
+	vehicles map[string]*ManagedVehicle
+}
+
+type managedAccount struct {
+	name   string
+	client *Client
+}
+
+// ManagedVehicle is a vehicle together with the name of the account it
+// belongs to.
+type ManagedVehicle struct {
+	*Vehicle
+	Account string
+}
+
+// AccountError is the error of a single account of a Manager.
+type AccountError struct {
+	Account string
+	Err     error
+}
+
+func (e *AccountError) Error() string { return e.Account + ": " + e.Err.Error() }
+func (e *AccountError) Unwrap() error { return e.Err }
+
+// AccountErrors collects the errors of all failed accounts. errors.Is
+// reports whether any of them matches.
+type AccountErrors []*AccountError
+
+func (e AccountErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("%d accounts failed: %s", len(e), strings.Join(msgs, "; "))
+}
+
+func (e AccountErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewManager returns a Manager without accounts.
+func NewManager() *Manager {
+	return &Manager{vehicles: map[string]*ManagedVehicle{}}
+}
+
+// Add registers the client of an account under a unique name.
+func (m *Manager) Add(name string, c *Client) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, a := range m.accounts {
+		if a.name == name {
+			return fmt.Errorf("%s: %w", name, ErrDuplicateAccount)
+		}
+	}
+	m.accounts = append(m.accounts, &managedAccount{name: name, client: c})
+	return nil
+}
+
+// AddConfig creates a Client for the account and registers it under name.
+func (m *Manager) AddConfig(name string, cfg Config, opts ...ClientOptions) error {
+	c, err := NewClient(cfg, opts...)
+	if err != nil {
+		return &AccountError{Account: name, Err: err}
+	}
+	return m.Add(name, c)
+}
+
+// Remove unregisters an account and forgets its vehicles.
+func (m *Manager) Remove(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, a := range m.accounts {
+		if a.name == name {
+			m.accounts = append(m.accounts[:i:i], m.accounts[i+1:]...)
+			break
+		}
+	}
+	for vin, v := range m.vehicles {
+		if v.Account == name {
+			delete(m.vehicles, vin)
+		}
+	}
+}
+
+// Accounts returns the names of all accounts in the order they were added.
+func (m *Manager) Accounts() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make([]string, 0, len(m.accounts))
+	for _, a := range m.accounts {
+		names = append(names, a.name)
+	}
+	return names
+}
+
+// Client returns the client of the named account.
+func (m *Manager) Client(name string) (*Client, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, a := range m.accounts {
+		if a.name == name {
+			return a.client, true
+		}
+	}
+	return nil, false
+}
+
+// Authenticate authenticates all accounts concurrently. The returned
+// AccountErrors hold the accounts that failed.
+func (m *Manager) Authenticate() error {
+	return m.each(func(a *managedAccount) error {
+		return a.client.Authenticate()
+	})
+}
+
+// Vehicles fetches the vehicles of all accounts concurrently and returns
+// them keyed by VIN. A vehicle shared between accounts is assigned to the
+// account added first. An account without vehicles is no failure. Vehicles
+// of accounts that failed are kept from the previous call, the failures are
+// returned as AccountErrors. Accounts removed meanwhile are left out.
+func (m *Manager) Vehicles() (map[string]*ManagedVehicle, error) {
+	m.mu.RLock()
+	accounts := append([]*managedAccount(nil), m.accounts...)
+	m.mu.RUnlock()
+
+	type result struct {
+		vehicles []*Vehicle
+		failed   bool
+	}
+	results := make([]result, len(accounts))
+	err := m.eachOf(accounts, func(i int, a *managedAccount) error {
+		vs, err := a.client.Vehicles()
+		if errors.Is(err, ErrNoVehicleFound) {
+			vs, err = nil, nil
+		}
+		results[i] = result{vehicles: vs, failed: err != nil}
+		return err
+	})
+
+	fetched := make(map[*managedAccount]result, len(accounts))
+	for i, a := range accounts {
+		fetched[a] = results[i]
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	vehicles := map[string]*ManagedVehicle{}
+	for _, a := range m.accounts {
+		r, ok := fetched[a]
+		if !ok || r.failed {
+			// added meanwhile or failed, keep what is known
+			for vin, v := range m.vehicles {
+				if v.Account == a.name {
+					vehicles[vin] = v
+				}
+			}
+			continue
+		}
+		for _, v := range r.vehicles {
+			if _, ok := vehicles[v.VIN()]; !ok {
+				vehicles[v.VIN()] = &ManagedVehicle{Vehicle: v, Account: a.name}
+			}
+		}
+	}
+	m.vehicles = vehicles
+
+	return m.snapshot(), err
+}
+
+// Vehicle returns the vehicle with the given VIN as found by the last call
+// to Vehicles.
+func (m *Manager) Vehicle(vin string) (*ManagedVehicle, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if v, ok := m.vehicles[vin]; ok {
+		return v, nil
+	}
+	return nil, fmt.Errorf("%s: %w", vin, ErrNoVehicleFound)
+}
+
+// VINs returns the sorted VINs found by the last call to Vehicles.
+func (m *Manager) VINs() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	vins := make([]string, 0, len(m.vehicles))
+	for vin := range m.vehicles {
+		vins = append(vins, vin)
+	}
+	sort.Strings(vins)
+	return vins
+}
+
+func (m *Manager) snapshot() map[string]*ManagedVehicle {
+	vehicles := make(map[string]*ManagedVehicle, len(m.vehicles))
+	for vin, v := range m.vehicles {
+		vehicles[vin] = v
+	}
+	return vehicles
+}
+
+func (m *Manager) each(f func(*managedAccount) error) error {
+	m.mu.RLock()
+	accounts := append([]*managedAccount(nil), m.accounts...)
+	m.mu.RUnlock()
+
+	return m.eachOf(accounts, func(_ int, a *managedAccount) error { return f(a) })
+}
+
+// eachOf calls f for all accounts concurrently and collects the failures in
+// the order of the accounts.
+func (m *Manager) eachOf(accounts []*managedAccount, f func(int, *managedAccount) error) error {
+	errs := make([]error, len(accounts))
+	var wg sync.WaitGroup
+	for i, a := range accounts {
+		wg.Add(1)
+		go func(i int, a *managedAccount) {
+			defer wg.Done()
+			errs[i] = f(i, a)
+		}(i, a)
+	}
+	wg.Wait()
+
+	var failed AccountErrors
+	for i, err := range errs {
+		if err != nil {
+			failed = append(failed, &AccountError{Account: accounts[i].name, Err: err})
+		}
+	}
+	if len(failed) > 0 {
+		return failed
+	}
+	return nil
+}
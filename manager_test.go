@@ -0,0 +1,79 @@
+package goblue_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/frzifus/goblue"
+	"github.com/frzifus/goblue/bluelinktest"
+)
+
+var managerCredentials = bluelinktest.Credentials{Username: "user", Password: "secret", PIN: "1234"}
+
+func newManagedClient(t *testing.T, m *goblue.Manager, name string, vins []string, opts ...goblue.ClientOptions) {
+	t.Helper()
+	srv := bluelinktest.NewServer(managerCredentials)
+	t.Cleanup(srv.Close)
+	for i, vin := range vins {
+		srv.AddVehicle(bluelinktest.Vehicle{ID: name + string(rune('0'+i)), VIN: vin, Type: "EV"})
+	}
+
+	c, err := goblue.NewClient(srv.Config(goblue.BrandKia), append(srv.ClientOptions(), opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Authenticate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Add(name, c); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestManagerAccountWithoutVehicles(t *testing.T) {
+	m := goblue.NewManager()
+	newManagedClient(t, m, "a", []string{"VIN-A"})
+	newManagedClient(t, m, "empty", nil)
+
+	vs, err := m.Vehicles()
+	if err != nil {
+		t.Fatalf("account without vehicles failed: %v", err)
+	}
+	if len(vs) != 1 || vs["VIN-A"] == nil {
+		t.Errorf("got vehicles %v, want VIN-A only", vs)
+	}
+}
+
+func TestManagerRemoveDuringVehicles(t *testing.T) {
+	m := goblue.NewManager()
+	newManagedClient(t, m, "a", []string{"VIN-A"})
+
+	fetching, removed := make(chan struct{}), make(chan struct{})
+	block := func(next goblue.HttpClient) goblue.HttpClient {
+		return goblue.HttpClientFunc(func(req *http.Request) (*http.Response, error) {
+			if strings.HasSuffix(req.URL.Path, "/spa/vehicles") {
+				close(fetching)
+				<-removed
+			}
+			return next.Do(req)
+		})
+	}
+	newManagedClient(t, m, "b", []string{"VIN-B"}, goblue.WithMiddleware(block))
+
+	go func() {
+		<-fetching
+		m.Remove("b")
+		close(removed)
+	}()
+	vs, err := m.Vehicles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := vs["VIN-B"]; ok {
+		t.Error("vehicle of the removed account was added again")
+	}
+	if vins := m.VINs(); len(vins) != 1 || vins[0] != "VIN-A" {
+		t.Errorf("got VINs %v, want [VIN-A]", vins)
+	}
+}
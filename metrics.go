@@ -0,0 +1,200 @@
+package goblue
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MetricsRecorder receives measurements of api calls. Implementations must be
+// safe for concurrent use.
+type MetricsRecorder interface {
+	// RecordCall is invoked once per api call. The status code is 0 and the
+	// resCode empty if no response was received.
+	RecordCall(endpoint string, statusCode int, resCode string, d time.Duration, err error)
+	// RecordTokenRefresh is invoked once per authentication.
+	RecordTokenRefresh(err error)
+}
+
+// WithMetrics reports every api call and authentication to the recorder.
+func WithMetrics(r MetricsRecorder) ClientOptions {
+	return func(c *Client) error {
+		c.metrics = r
+		c.middleware = append(c.middleware, metricsMiddleware(r))
+		return nil
+	}
+}
+
+// endpointName identifies the endpoint of a request by its method and path,
+// with ids replaced by a placeholder.
+func endpointName(req *http.Request) string {
+	return req.Method + " " + idPattern.ReplaceAllString(req.URL.Path, "{id}")
+}
+
+func metricsMiddleware(r MetricsRecorder) Middleware {
+	return func(next HttpClient) HttpClient {
+		return HttpClientFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next.Do(req)
+			d := time.Since(start)
+
+			var statusCode int
+			var resCode string
+			if resp != nil {
+				statusCode = resp.StatusCode
+				body, err := peekBody(&resp.Body)
+				if err != nil {
+					return nil, err
+				}
+				msg := struct {
+					Rescode string `json:"resCode"`
+				}{}
+				if json.Unmarshal(body, &msg) == nil {
+					resCode = msg.Rescode
+				}
+			}
+			r.RecordCall(endpointName(req), statusCode, resCode, d, err)
+
+			return resp, err
+		})
+	}
+}
+
+// DefaultLatencyBuckets are the upper bounds in seconds of the latency
+// histogram kept by Metrics.
+var DefaultLatencyBuckets = []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30}
+
+// Metrics aggregates api measurements in memory. Its snapshot maps directly
+// to counters and histograms of metric backends like Prometheus.
+type Metrics struct {
+	mu        sync.Mutex
+	requests  map[[2]string]uint64
+	errors    map[[2]string]uint64
+	latencies map[string]*Latency
+
+	tokenRefreshes       uint64
+	tokenRefreshFailures uint64
+}
+
+// RequestCount is the number of calls to an endpoint answered with a status
+// code.
+type RequestCount struct {
+	Endpoint   string
+	StatusCode string
+	Count      uint64
+}
+
+// ErrorCount is the number of failed calls to an endpoint by resCode. Calls
+// failing without response are counted with the resCode "network", failed
+// responses without resCode by their status code like "http_429".
+type ErrorCount struct {
+	Endpoint string
+	ResCode  string
+	Count    uint64
+}
+
+// Latency is a histogram of the call durations of an endpoint in seconds.
+// Buckets are cumulative and keyed by their upper bound.
+type Latency struct {
+	Endpoint string
+	Count    uint64
+	Sum      float64
+	Buckets  map[float64]uint64
+}
+
+// MetricsSnapshot is a consistent copy of the values collected by Metrics.
+type MetricsSnapshot struct {
+	Requests             []RequestCount
+	Errors               []ErrorCount
+	Latencies            []Latency
+	TokenRefreshes       uint64
+	TokenRefreshFailures uint64
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{
+		requests:  map[[2]string]uint64{},
+		errors:    map[[2]string]uint64{},
+		latencies: map[string]*Latency{},
+	}
+}
+
+func (m *Metrics) RecordCall(endpoint string, statusCode int, resCode string, d time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests[[2]string{endpoint, strconv.Itoa(statusCode)}]++
+	switch {
+	case err != nil:
+		m.errors[[2]string{endpoint, "network"}]++
+	case resCode != "" && resCode != "0000":
+		m.errors[[2]string{endpoint, resCode}]++
+	case statusCode >= http.StatusBadRequest:
+		m.errors[[2]string{endpoint, "http_" + strconv.Itoa(statusCode)}]++
+	}
+
+	l, ok := m.latencies[endpoint]
+	if !ok {
+		l = &Latency{Endpoint: endpoint, Buckets: map[float64]uint64{}}
+		m.latencies[endpoint] = l
+	}
+	seconds := d.Seconds()
+	l.Count++
+	l.Sum += seconds
+	for _, b := range DefaultLatencyBuckets {
+		if seconds <= b {
+			l.Buckets[b]++
+		}
+	}
+}
+
+func (m *Metrics) RecordTokenRefresh(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.tokenRefreshes++
+	if err != nil {
+		m.tokenRefreshFailures++
+	}
+}
+
+// Snapshot returns the current values sorted by endpoint.
+func (m *Metrics) Snapshot() MetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := MetricsSnapshot{
+		TokenRefreshes:       m.tokenRefreshes,
+		TokenRefreshFailures: m.tokenRefreshFailures,
+	}
+	for k, v := range m.requests {
+		s.Requests = append(s.Requests, RequestCount{Endpoint: k[0], StatusCode: k[1], Count: v})
+	}
+	for k, v := range m.errors {
+		s.Errors = append(s.Errors, ErrorCount{Endpoint: k[0], ResCode: k[1], Count: v})
+	}
+	for _, l := range m.latencies {
+		buckets := make(map[float64]uint64, len(l.Buckets))
+		for b, c := range l.Buckets {
+			buckets[b] = c
+		}
+		s.Latencies = append(s.Latencies, Latency{Endpoint: l.Endpoint, Count: l.Count, Sum: l.Sum, Buckets: buckets})
+	}
+
+	sort.Slice(s.Requests, func(i, j int) bool {
+		a, b := s.Requests[i], s.Requests[j]
+		return a.Endpoint < b.Endpoint || (a.Endpoint == b.Endpoint && a.StatusCode < b.StatusCode)
+	})
+	sort.Slice(s.Errors, func(i, j int) bool {
+		a, b := s.Errors[i], s.Errors[j]
+		return a.Endpoint < b.Endpoint || (a.Endpoint == b.Endpoint && a.ResCode < b.ResCode)
+	})
+	sort.Slice(s.Latencies, func(i, j int) bool {
+		return s.Latencies[i].Endpoint < s.Latencies[j].Endpoint
+	})
+
+	return s
+}
@@ -0,0 +1,75 @@
+package goblue
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMetricsRecordCall(t *testing.T) {
+	const endpoint = "GET /api/v1/spa/vehicles/{id}/status/latest"
+	m := NewMetrics()
+	m.RecordCall(endpoint, http.StatusOK, "0000", 30*time.Millisecond, nil)
+	m.RecordCall(endpoint, http.StatusOK, "4081", time.Second, nil)
+	m.RecordCall(endpoint, http.StatusBadRequest, "4004", 30*time.Millisecond, nil)
+	m.RecordCall(endpoint, http.StatusUnauthorized, "", 30*time.Millisecond, nil)
+	m.RecordCall(endpoint, http.StatusTooManyRequests, "", 30*time.Millisecond, nil)
+	m.RecordCall(endpoint, http.StatusTooManyRequests, "", 30*time.Millisecond, nil)
+	m.RecordCall(endpoint, http.StatusBadGateway, "", 30*time.Millisecond, nil)
+	m.RecordCall(endpoint, 0, "", time.Minute, errors.New("connection refused"))
+	m.RecordTokenRefresh(nil)
+	m.RecordTokenRefresh(errors.New("invalid password"))
+
+	s := m.Snapshot()
+	wantRequests := []RequestCount{
+		{endpoint, "0", 1},
+		{endpoint, "200", 2},
+		{endpoint, "400", 1},
+		{endpoint, "401", 1},
+		{endpoint, "429", 2},
+		{endpoint, "502", 1},
+	}
+	if !reflect.DeepEqual(s.Requests, wantRequests) {
+		t.Errorf("requests:\ngot  %v\nwant %v", s.Requests, wantRequests)
+	}
+	wantErrors := []ErrorCount{
+		{endpoint, "4004", 1},
+		{endpoint, "4081", 1},
+		{endpoint, "http_401", 1},
+		{endpoint, "http_429", 2},
+		{endpoint, "http_502", 1},
+		{endpoint, "network", 1},
+	}
+	if !reflect.DeepEqual(s.Errors, wantErrors) {
+		t.Errorf("errors:\ngot  %v\nwant %v", s.Errors, wantErrors)
+	}
+
+	if len(s.Latencies) != 1 {
+		t.Fatalf("got %d latencies, want 1", len(s.Latencies))
+	}
+	l := s.Latencies[0]
+	if l.Count != 8 || l.Buckets[.05] != 6 || l.Buckets[1] != 7 || l.Buckets[30] != 7 {
+		t.Errorf("got latency %+v", l)
+	}
+	if s.TokenRefreshes != 2 || s.TokenRefreshFailures != 1 {
+		t.Errorf("got %d token refreshes, %d failed, want 2, 1", s.TokenRefreshes, s.TokenRefreshFailures)
+	}
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	m := NewMetrics()
+	failing := HttpClientFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("connection reset")
+	})
+	req, _ := http.NewRequest(http.MethodGet, "https://example.com/api/v1/spa/vehicles/00000000-0000-0000-0000-000000000001/status", nil)
+	if _, err := metricsMiddleware(m)(failing).Do(req); err == nil {
+		t.Fatal("transport failure was swallowed")
+	}
+
+	want := []ErrorCount{{"GET /api/v1/spa/vehicles/{id}/status", "network", 1}}
+	if got := m.Snapshot().Errors; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
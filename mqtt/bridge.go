@@ -0,0 +1,379 @@
+// Package mqtt bridges vehicles to an MQTT broker for home automation.
+//
+// The Bridge publishes the status of each vehicle to retained topics below
+// <prefix>/<vin>/ and announces the vehicles to Home Assistant by discovery
+// payloads. Commands published to <prefix>/<vin>/lock/set, climate/set and
+// charge/set are sent to the vehicle, a command is only subscribed to if the
+// capabilities of the vehicle support it:
+//
+//	goblue/<vin>/locked        LOCKED | UNLOCKED
+//	goblue/<vin>/charging      ON | OFF
+//	goblue/<vin>/plugged_in    ON | OFF
+//	goblue/<vin>/windows_open  ON | OFF
+//	goblue/<vin>/soc           state of charge in percent
+//	goblue/<vin>/range         range left in km
+//	goblue/<vin>/target_soc_ac charge limit in percent
+//	goblue/<vin>/target_soc_dc charge limit in percent
+//	goblue/<vin>/updated_at    RFC 3339 time of the status
+//	goblue/<vin>/status        the whole status as json
+//
+//	goblue/<vin>/lock/set      LOCK | UNLOCK
+//	goblue/<vin>/climate/set   ON | OFF
+//	goblue/<vin>/charge/set    ON | OFF
+//
+// Commands of a vehicle are sent one after another, while a command waits for
+// the vehicle to respond the states and commands of other vehicles are still
+// handled. The availability of the bridge is published to <prefix>/status.
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/frzifus/goblue"
+)
+
+const (
+	DefaultTopicPrefix     = "goblue"
+	DefaultDiscoveryPrefix = "homeassistant"
+
+	// DefaultInterval keeps the bridged vehicles well below the daily
+	// request limit of the api.
+	DefaultInterval = goblue.DefaultWatchInterval
+
+	payloadOn       = "ON"
+	payloadOff      = "OFF"
+	payloadLock     = "LOCK"
+	payloadUnlock   = "UNLOCK"
+	payloadLocked   = "LOCKED"
+	payloadUnlocked = "UNLOCKED"
+	payloadOnline   = "online"
+	payloadOffline  = "offline"
+
+	commandBuffer   = 16
+	disconnectDelay = 250 // ms
+)
+
+// ErrInvalidCommand is returned for a command topic or payload the bridge
+// does not understand.
+var ErrInvalidCommand = errors.New("invalid command")
+
+type Option func(*Bridge)
+
+// WithTopicPrefix sets the prefix of all state and command topics.
+func WithTopicPrefix(prefix string) Option {
+	return func(b *Bridge) {
+		b.prefix = strings.TrimSuffix(prefix, "/")
+	}
+}
+
+// WithDiscoveryPrefix sets the Home Assistant discovery prefix. An empty
+// prefix disables discovery.
+func WithDiscoveryPrefix(prefix string) Option {
+	return func(b *Bridge) {
+		b.discoveryPrefix = strings.TrimSuffix(prefix, "/")
+	}
+}
+
+// WithInterval sets the time between two polls of a vehicle.
+func WithInterval(d time.Duration) Option {
+	return func(b *Bridge) {
+		b.interval = d
+	}
+}
+
+// WithQoS sets the quality of service of published messages and command
+// subscriptions.
+func WithQoS(qos byte) Option {
+	return func(b *Bridge) {
+		b.qos = qos
+	}
+}
+
+// WithLogger logs published states and failed commands.
+func WithLogger(l goblue.Logger) Option {
+	return func(b *Bridge) {
+		b.logger = l
+	}
+}
+
+// Bridge polls the status of vehicles, publishes it to an MQTT broker and
+// forwards commands received from the broker to the vehicles.
+type Bridge struct {
+	client   paho.Client
+	api      *goblue.Client
+	vehicles []*goblue.Vehicle
+	byVIN    map[string]*goblue.Vehicle
+
+	prefix          string
+	discoveryPrefix string
+	interval        time.Duration
+	qos             byte
+	logger          goblue.Logger
+
+	// commands queues the commands of each vehicle for its worker
+	commands map[*goblue.Vehicle]chan command
+}
+
+// command is a command received on a command topic.
+type command struct {
+	vehicle *goblue.Vehicle
+	name    string
+	payload string
+}
+
+// NewBridge returns a Bridge for the given vehicles of the api client, which
+// is authenticated again once its session expired. The MQTT client is created
+// from opts once the bridge set its last will and connect handler, it
+// connects once Run is called.
+func NewBridge(opts *paho.ClientOptions, api *goblue.Client, vehicles []*goblue.Vehicle, options ...Option) *Bridge {
+	b := &Bridge{
+		api:             api,
+		vehicles:        vehicles,
+		byVIN:           make(map[string]*goblue.Vehicle, len(vehicles)),
+		prefix:          DefaultTopicPrefix,
+		discoveryPrefix: DefaultDiscoveryPrefix,
+		interval:        DefaultInterval,
+		qos:             1,
+		logger:          nopLogger{},
+		commands:        make(map[*goblue.Vehicle]chan command, len(vehicles)),
+	}
+	for _, o := range options {
+		o(b)
+	}
+	for _, v := range vehicles {
+		b.byVIN[v.VIN()] = v
+		b.commands[v] = make(chan command, commandBuffer)
+	}
+
+	opts.SetWill(b.availabilityTopic(), payloadOffline, b.qos, true)
+	opts.SetOnConnectHandler(b.onConnect)
+	b.client = paho.NewClient(opts)
+	return b
+}
+
+// Run connects to the broker and polls the vehicles by a goblue.Watcher
+// until the context is done and returns its error. Once the api reports
+// ErrRateLimited the interval doubles until a poll succeeds again.
+func (b *Bridge) Run(ctx context.Context) error {
+	if t := b.client.Connect(); t.Wait() && t.Error() != nil {
+		return t.Error()
+	}
+	defer b.client.Disconnect(disconnectDelay)
+	defer b.publish(b.availabilityTopic(), payloadOffline)
+
+	// commands in flight are cancelled and waited for before going offline
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for _, v := range b.vehicles {
+		wg.Add(1)
+		go func(queue <-chan command) {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case c := <-queue:
+					b.execute(ctx, c)
+				}
+			}
+		}(b.commands[v])
+	}
+
+	w := goblue.NewWatcher(b.vehicles,
+		goblue.WithWatchInterval(b.interval),
+		goblue.WithWatchClient(b.api),
+		goblue.WithStatusUpdates(),
+	)
+	done := make(chan error, 1)
+	go func() { done <- w.Run(ctx) }()
+
+	for e := range w.Events() {
+		switch e := e.(type) {
+		case goblue.StatusUpdated:
+			b.publishStatus(e.Vehicle, e.Current)
+		case goblue.StatusFailed:
+			b.logger.Error("status failed", "vin", e.Vehicle.VIN(), "err", e.Err)
+		}
+	}
+	return <-done
+}
+
+// onConnect subscribes to the command topics supported by the vehicles, announces the vehicles and
+// reports the bridge online, it runs on every reconnect.
+func (b *Bridge) onConnect(c paho.Client) {
+	filters := map[string]byte{}
+	for _, v := range b.vehicles {
+		for _, name := range commandNames(v) {
+			filters[b.commandTopic(v, name)] = b.qos
+		}
+	}
+	if len(filters) > 0 {
+		if t := c.SubscribeMultiple(filters, b.onCommand); t.Wait() && t.Error() != nil {
+			b.logger.Error("subscribe failed", "err", t.Error())
+		}
+	}
+
+	for _, v := range b.vehicles {
+		if err := b.announce(v); err != nil {
+			b.logger.Error("discovery failed", "vin", v.VIN(), "err", err)
+		}
+	}
+	b.publish(b.availabilityTopic(), payloadOnline)
+}
+
+// onCommand queues a command for the worker of its vehicle. Commands are
+// dropped while the queue is full since the message handler must not block.
+func (b *Bridge) onCommand(_ paho.Client, m paho.Message) {
+	c, err := b.parseCommand(m.Topic(), string(m.Payload()))
+	if err != nil {
+		b.logger.Error("command rejected", "topic", m.Topic(), "err", err)
+		return
+	}
+	select {
+	case b.commands[c.vehicle] <- c:
+	default:
+		b.logger.Error("command dropped", "topic", m.Topic())
+	}
+}
+
+func (b *Bridge) parseCommand(topic, payload string) (command, error) {
+	parts := strings.Split(strings.TrimPrefix(topic, b.prefix+"/"), "/")
+	if len(parts) != 3 || parts[2] != "set" {
+		return command{}, fmt.Errorf("topic %s: %w", topic, ErrInvalidCommand)
+	}
+	v, ok := b.byVIN[parts[0]]
+	if !ok {
+		return command{}, fmt.Errorf("vehicle %s: %w", parts[0], goblue.ErrNoVehicleFound)
+	}
+	if !supports(v, parts[1]) {
+		return command{}, fmt.Errorf("%s %s: %w", parts[0], parts[1], goblue.ErrUnsupported)
+	}
+	return command{vehicle: v, name: parts[1], payload: strings.TrimSpace(payload)}, nil
+}
+
+// execute sends a command to the vehicle and publishes the status it
+// resulted in.
+func (b *Bridge) execute(ctx context.Context, c command) {
+	v := c.vehicle
+	var send func(context.Context) error
+	switch {
+	case c.name == "lock" && c.payload == payloadLock:
+		send = v.LockContext
+	case c.name == "lock" && c.payload == payloadUnlock:
+		send = v.UnlockContext
+	case c.name == "climate" && c.payload == payloadOn:
+		send = func(ctx context.Context) error { return v.StartContext(ctx) }
+	case c.name == "climate" && c.payload == payloadOff:
+		send = v.StopContext
+	case c.name == "charge" && c.payload == payloadOn:
+		send = v.StartChargeContext
+	case c.name == "charge" && c.payload == payloadOff:
+		send = v.StopChargeContext
+	default:
+		b.logger.Error("command failed", "vin", v.VIN(), "command", c.name, "payload", c.payload, "err", ErrInvalidCommand)
+		return
+	}
+	if err := b.api.Reauthenticate(ctx, func() error { return send(ctx) }); err != nil {
+		b.logger.Error("command failed", "vin", v.VIN(), "command", c.name, "payload", c.payload, "err", err)
+		return
+	}
+	b.logger.Debug("command sent", "vin", v.VIN(), "command", c.name, "payload", c.payload)
+
+	var s *goblue.VehicleStatus
+	err := b.api.Reauthenticate(ctx, func() (err error) {
+		s, err = v.StatusContext(ctx)
+		return err
+	})
+	if err != nil {
+		b.logger.Error("status failed", "vin", v.VIN(), "err", err)
+		return
+	}
+	b.publishStatus(v, s)
+}
+
+// publishStatus publishes the status of a vehicle to its state topics.
+func (b *Bridge) publishStatus(v *goblue.Vehicle, s *goblue.VehicleStatus) {
+	status, err := json.Marshal(s)
+	if err != nil {
+		b.logger.Error("status encoding failed", "vin", v.VIN(), "err", err)
+		return
+	}
+
+	for topic, payload := range map[string]string{
+		"locked":        choose(s.DoorIsLocked(), payloadLocked, payloadUnlocked),
+		"charging":      choose(s.IsCharging(), payloadOn, payloadOff),
+		"plugged_in":    choose(s.PluggedIn(), payloadOn, payloadOff),
+		"windows_open":  choose(s.Windows().Open(), payloadOn, payloadOff),
+		"soc":           strconv.Itoa(s.SoC()),
+		"range":         strconv.Itoa(s.RangeLeft()),
+		"target_soc_ac": strconv.Itoa(s.TargetSocAC()),
+		"target_soc_dc": strconv.Itoa(s.TargetSocDC()),
+		"updated_at":    s.UpdatedAt().Format(time.RFC3339),
+		"status":        string(status),
+	} {
+		b.publish(b.stateTopic(v, topic), payload)
+	}
+	b.logger.Debug("status published", "vin", v.VIN())
+}
+
+// publish sends a retained message, failures are logged.
+func (b *Bridge) publish(topic, payload string) {
+	if t := b.client.Publish(topic, b.qos, true, payload); t.Wait() && t.Error() != nil {
+		b.logger.Error("publish failed", "topic", topic, "err", t.Error())
+	}
+}
+
+func (b *Bridge) availabilityTopic() string {
+	return b.prefix + "/status"
+}
+
+func (b *Bridge) stateTopic(v *goblue.Vehicle, name string) string {
+	return b.prefix + "/" + v.VIN() + "/" + name
+}
+
+func (b *Bridge) commandTopic(v *goblue.Vehicle, name string) string {
+	return b.prefix + "/" + v.VIN() + "/" + name + "/set"
+}
+
+// commandNames returns the commands the capabilities of a vehicle allow,
+// locking and climate control are available on all vehicles.
+func commandNames(v *goblue.Vehicle) []string {
+	names := []string{"lock", "climate"}
+	if supports(v, "charge") {
+		names = append(names, "charge")
+	}
+	return names
+}
+
+// supports reports whether the vehicle accepts the named command.
+func supports(v *goblue.Vehicle, name string) bool {
+	switch name {
+	case "charge":
+		return v.Capabilities().Has(goblue.CapabilityCharging)
+	}
+	return true
+}
+
+func choose(b bool, yes, no string) string {
+	if b {
+		return yes
+	}
+	return no
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+func (nopLogger) Error(string, ...interface{}) {}
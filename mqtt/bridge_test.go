@@ -0,0 +1,266 @@
+package mqtt_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/frzifus/goblue"
+	"github.com/frzifus/goblue/bluelinktest"
+	"github.com/frzifus/goblue/internal/mqtttest"
+	"github.com/frzifus/goblue/mqtt"
+)
+
+const (
+	evID   = "00000000-0000-0000-0000-0000000000a1"
+	evVIN  = "KMHTEST0000000001"
+	iceID  = "00000000-0000-0000-0000-0000000000b1"
+	iceVIN = "KMHTEST0000000002"
+)
+
+// discovery is the part of a discovery payload checked by the tests.
+type discovery struct {
+	StateTopic   string `json:"state_topic"`
+	CommandTopic string `json:"command_topic"`
+	DeviceClass  string `json:"device_class"`
+}
+
+// startBridge runs a bridge for an electric and a combustion vehicle and
+// waits until it is online. Vehicles are polled hourly unless the options
+// say otherwise.
+func startBridge(t *testing.T, options ...mqtt.Option) (*mqtttest.Broker, *bluelinktest.Server) {
+	t.Helper()
+	broker, err := mqtttest.NewBroker()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := bluelinktest.NewServer(bluelinktest.Credentials{Username: "user", Password: "secret", PIN: "1234"})
+	srv.AddVehicle(bluelinktest.Vehicle{
+		ID:   evID,
+		VIN:  evVIN,
+		Name: "Kona",
+		Type: "EV",
+		State: bluelinktest.State{
+			Locked:      true,
+			PluggedIn:   true,
+			SoC:         80,
+			Range:       320,
+			TargetSoCAC: 90,
+			TargetSoCDC: 80,
+			UpdatedAt:   time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		},
+	})
+	srv.AddVehicle(bluelinktest.Vehicle{
+		ID:       iceID,
+		VIN:      iceVIN,
+		Name:     "i30",
+		Type:     "GN",
+		Features: []string{"REMOTE_WINDOW"},
+		State:    bluelinktest.State{Locked: true, UpdatedAt: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)},
+	})
+
+	c, err := goblue.NewClient(srv.Config(goblue.BrandHyundai), srv.ClientOptions()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Authenticate(); err != nil {
+		t.Fatal(err)
+	}
+	vs, err := c.Vehicles()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := paho.NewClientOptions().AddBroker(broker.URL()).SetClientID("goblue-test")
+	b := mqtt.NewBridge(opts, c, vs, append([]mqtt.Option{mqtt.WithInterval(time.Hour)}, options...)...)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- b.Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; !errors.Is(err, context.Canceled) {
+			t.Errorf("Run returned %v, want context.Canceled", err)
+		}
+		broker.Close()
+		srv.Close()
+	})
+
+	waitRetained(t, broker, "goblue/status", "online")
+	return broker, srv
+}
+
+// waitRetained waits until the retained message of a topic equals want.
+func waitRetained(t *testing.T, broker *mqtttest.Broker, topic, want string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		p, _ := broker.Retained(topic)
+		if string(p) == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s: got %q, want %q", topic, p, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestBridgeDiscovery(t *testing.T) {
+	broker, _ := startBridge(t)
+
+	tests := []struct {
+		topic string
+		want  *discovery // nil if the entity must not be announced
+	}{
+		{"homeassistant/lock/" + evVIN + "/doors/config", &discovery{
+			StateTopic:   "goblue/" + evVIN + "/locked",
+			CommandTopic: "goblue/" + evVIN + "/lock/set",
+		}},
+		{"homeassistant/switch/" + evVIN + "/charge/config", &discovery{
+			StateTopic:   "goblue/" + evVIN + "/charging",
+			CommandTopic: "goblue/" + evVIN + "/charge/set",
+		}},
+		{"homeassistant/sensor/" + evVIN + "/soc/config", &discovery{
+			StateTopic:  "goblue/" + evVIN + "/soc",
+			DeviceClass: "battery",
+		}},
+		{"homeassistant/switch/" + evVIN + "/climate/config", &discovery{
+			CommandTopic: "goblue/" + evVIN + "/climate/set",
+		}},
+		{"homeassistant/lock/" + iceVIN + "/doors/config", &discovery{
+			StateTopic:   "goblue/" + iceVIN + "/locked",
+			CommandTopic: "goblue/" + iceVIN + "/lock/set",
+		}},
+		{"homeassistant/switch/" + iceVIN + "/climate/config", &discovery{
+			CommandTopic: "goblue/" + iceVIN + "/climate/set",
+		}},
+		{"homeassistant/switch/" + iceVIN + "/charge/config", nil},
+		{"homeassistant/sensor/" + iceVIN + "/soc/config", nil},
+	}
+
+	for _, tt := range tests {
+		p, ok := broker.Retained(tt.topic)
+		if tt.want == nil {
+			if ok {
+				t.Errorf("%s: unsupported entity announced: %s", tt.topic, p)
+			}
+			continue
+		}
+		if !ok {
+			t.Errorf("%s: not announced", tt.topic)
+			continue
+		}
+		var got discovery
+		if err := json.Unmarshal(p, &got); err != nil {
+			t.Errorf("%s: %v", tt.topic, err)
+			continue
+		}
+		if got != *tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.topic, got, *tt.want)
+		}
+	}
+}
+
+func TestBridgeState(t *testing.T) {
+	broker, _ := startBridge(t)
+
+	for topic, want := range map[string]string{
+		"locked":        "LOCKED",
+		"charging":      "OFF",
+		"plugged_in":    "ON",
+		"windows_open":  "OFF",
+		"soc":           "80",
+		"range":         "320",
+		"target_soc_ac": "90",
+		"target_soc_dc": "80",
+	} {
+		waitRetained(t, broker, "goblue/"+evVIN+"/"+topic, want)
+	}
+	waitRetained(t, broker, "goblue/"+iceVIN+"/locked", "LOCKED")
+
+	p, _ := broker.Retained("goblue/" + evVIN + "/status")
+	var status map[string]interface{}
+	if err := json.Unmarshal(p, &status); err != nil {
+		t.Errorf("status: %v: %s", err, p)
+	}
+}
+
+func TestBridgeCommands(t *testing.T) {
+	broker, srv := startBridge(t)
+	waitRetained(t, broker, "goblue/"+evVIN+"/locked", "LOCKED")
+
+	broker.Publish("goblue/"+evVIN+"/lock/set", []byte("UNLOCK"), false)
+	waitRetained(t, broker, "goblue/"+evVIN+"/locked", "UNLOCKED")
+	if st, _ := srv.State(evID); st.Locked {
+		t.Error("vehicle still locked")
+	}
+
+	// unsupported commands are not subscribed to and never reach the api,
+	// the climate command that follows is handled once they were dropped
+	broker.Publish("goblue/"+iceVIN+"/charge/set", []byte("ON"), false)
+	broker.Publish("goblue/"+iceVIN+"/climate/set", []byte("ON"), false)
+
+	cmds := waitCommands(t, srv, 2, 5*time.Second)
+	if cmds[0].VehicleID != evID || cmds[0].Name != "door" ||
+		cmds[1].VehicleID != iceID || cmds[1].Name != "temperature" {
+		t.Fatalf("got commands %+v, want an unlock and a climate start", cmds)
+	}
+	if st, _ := srv.State(iceID); st.Charging {
+		t.Error("unsupported charge command reached the vehicle")
+	}
+}
+
+// waitCommands waits until the server received n commands.
+func waitCommands(t *testing.T, srv *bluelinktest.Server, n int, timeout time.Duration) []bluelinktest.Command {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for len(srv.Commands()) < n && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cmds := srv.Commands()
+	if len(cmds) != n {
+		t.Fatalf("got commands %+v, want %d", cmds, n)
+	}
+	return cmds
+}
+
+func TestBridgeReauthenticates(t *testing.T) {
+	broker, srv := startBridge(t, mqtt.WithInterval(20*time.Millisecond))
+	waitRetained(t, broker, "goblue/"+evVIN+"/soc", "80")
+
+	srv.ExpireTokens()
+	st, _ := srv.State(evID)
+	st.SoC = 81
+	srv.SetState(evID, st)
+	waitRetained(t, broker, "goblue/"+evVIN+"/soc", "81")
+
+	srv.ExpireTokens()
+	broker.Publish("goblue/"+evVIN+"/lock/set", []byte("UNLOCK"), false)
+	waitCommands(t, srv, 1, 5*time.Second)
+	waitRetained(t, broker, "goblue/"+evVIN+"/locked", "UNLOCKED")
+	if st, _ := srv.State(evID); st.Locked {
+		t.Error("vehicle still locked")
+	}
+}
+
+func TestBridgeSlowCommandDoesNotBlock(t *testing.T) {
+	broker, srv := startBridge(t)
+	// commands never complete, they wait until the bridge stops
+	srv.SetCommandResult("pending")
+
+	broker.Publish("goblue/"+evVIN+"/lock/set", []byte("LOCK"), false)
+	waitCommands(t, srv, 1, 5*time.Second)
+
+	// the records are polled every 2s, the second vehicle is served before
+	broker.Publish("goblue/"+iceVIN+"/climate/set", []byte("ON"), false)
+	cmds := waitCommands(t, srv, 2, time.Second)
+	if cmds[1].VehicleID != iceID {
+		t.Errorf("got commands %+v, want the climate start of the second vehicle", cmds)
+	}
+}
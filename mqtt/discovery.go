@@ -0,0 +1,168 @@
+package mqtt
+
+import (
+	"encoding/json"
+
+	"github.com/frzifus/goblue"
+)
+
+// discoveryConfig is the config of a Home Assistant entity, see
+// https://www.home-assistant.io/integrations/mqtt/#mqtt-discovery
+type discoveryConfig struct {
+	Name              string `json:"name"`
+	UniqueID          string `json:"unique_id"`
+	Device            device `json:"device"`
+	AvailabilityTopic string `json:"availability_topic"`
+	StateTopic        string `json:"state_topic,omitempty"`
+	CommandTopic      string `json:"command_topic,omitempty"`
+	DeviceClass       string `json:"device_class,omitempty"`
+	StateClass        string `json:"state_class,omitempty"`
+	Unit              string `json:"unit_of_measurement,omitempty"`
+	Icon              string `json:"icon,omitempty"`
+	PayloadOn         string `json:"payload_on,omitempty"`
+	PayloadOff        string `json:"payload_off,omitempty"`
+	PayloadLock       string `json:"payload_lock,omitempty"`
+	PayloadUnlock     string `json:"payload_unlock,omitempty"`
+	StateLocked       string `json:"state_locked,omitempty"`
+	StateUnlocked     string `json:"state_unlocked,omitempty"`
+}
+
+type device struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer"`
+	Model        string   `json:"model,omitempty"`
+}
+
+// entity is a Home Assistant entity of a vehicle.
+type entity struct {
+	component string
+	object    string
+	config    discoveryConfig
+}
+
+// announce publishes the discovery configs of all entities of a vehicle.
+func (b *Bridge) announce(v *goblue.Vehicle) error {
+	if b.discoveryPrefix == "" {
+		return nil
+	}
+	for _, e := range b.entities(v) {
+		payload, err := json.Marshal(e.config)
+		if err != nil {
+			return err
+		}
+		b.publish(b.discoveryPrefix+"/"+e.component+"/"+v.VIN()+"/"+e.object+"/config", string(payload))
+	}
+	return nil
+}
+
+// entities returns the entities supported by the capabilities of a vehicle.
+func (b *Bridge) entities(v *goblue.Vehicle) []entity {
+	dev := device{
+		Identifiers:  []string{v.VIN()},
+		Name:         v.Name(),
+		Manufacturer: manufacturer(v.Brand()),
+		Model:        v.Info().ModelName,
+	}
+	if dev.Name == "" {
+		dev.Name = v.VIN()
+	}
+	config := func(object, name string) discoveryConfig {
+		return discoveryConfig{
+			Name:              name,
+			UniqueID:          "goblue_" + v.VIN() + "_" + object,
+			Device:            dev,
+			AvailabilityTopic: b.availabilityTopic(),
+		}
+	}
+
+	doors := config("doors", "Doors")
+	doors.StateTopic = b.stateTopic(v, "locked")
+	doors.CommandTopic = b.commandTopic(v, "lock")
+	doors.PayloadLock, doors.PayloadUnlock = payloadLock, payloadUnlock
+	doors.StateLocked, doors.StateUnlocked = payloadLocked, payloadUnlocked
+
+	rangeLeft := config("range", "Range")
+	rangeLeft.StateTopic = b.stateTopic(v, "range")
+	rangeLeft.DeviceClass, rangeLeft.StateClass, rangeLeft.Unit = "distance", "measurement", "km"
+
+	updated := config("updated_at", "Last update")
+	updated.StateTopic = b.stateTopic(v, "updated_at")
+	updated.DeviceClass = "timestamp"
+
+	windows := config("windows", "Windows")
+	windows.StateTopic = b.stateTopic(v, "windows_open")
+	windows.DeviceClass = "window"
+	windows.PayloadOn, windows.PayloadOff = payloadOn, payloadOff
+
+	// the status does not report the climate control, the switch is
+	// optimistic without a state topic
+	climate := config("climate", "Climate")
+	climate.CommandTopic = b.commandTopic(v, "climate")
+	climate.Icon = "mdi:air-conditioner"
+	climate.PayloadOn, climate.PayloadOff = payloadOn, payloadOff
+
+	entities := []entity{
+		{"lock", "doors", doors},
+		{"sensor", "range", rangeLeft},
+		{"sensor", "updated_at", updated},
+		{"binary_sensor", "windows", windows},
+		{"switch", "climate", climate},
+	}
+
+	caps := v.Capabilities()
+	if !supports(v, "charge") {
+		return entities
+	}
+
+	soc := config("soc", "Battery")
+	soc.StateTopic = b.stateTopic(v, "soc")
+	soc.DeviceClass, soc.StateClass, soc.Unit = "battery", "measurement", "%"
+
+	charging := config("charging", "Charging")
+	charging.StateTopic = b.stateTopic(v, "charging")
+	charging.DeviceClass = "battery_charging"
+	charging.PayloadOn, charging.PayloadOff = payloadOn, payloadOff
+
+	plug := config("plugged_in", "Plugged in")
+	plug.StateTopic = b.stateTopic(v, "plugged_in")
+	plug.DeviceClass = "plug"
+	plug.PayloadOn, plug.PayloadOff = payloadOn, payloadOff
+
+	charge := config("charge", "Charge")
+	charge.StateTopic = b.stateTopic(v, "charging")
+	charge.CommandTopic = b.commandTopic(v, "charge")
+	charge.Icon = "mdi:ev-station"
+	charge.PayloadOn, charge.PayloadOff = payloadOn, payloadOff
+
+	entities = append(entities,
+		entity{"sensor", "soc", soc},
+		entity{"binary_sensor", "charging", charging},
+		entity{"binary_sensor", "plugged_in", plug},
+		entity{"switch", "charge", charge},
+	)
+
+	if caps.Has(goblue.CapabilityChargeLimits) {
+		for _, limit := range []struct{ object, name string }{
+			{"target_soc_ac", "Charge limit AC"},
+			{"target_soc_dc", "Charge limit DC"},
+		} {
+			c := config(limit.object, limit.name)
+			c.StateTopic = b.stateTopic(v, limit.object)
+			c.Unit = "%"
+			c.Icon = "mdi:battery-charging-high"
+			entities = append(entities, entity{"sensor", limit.object, c})
+		}
+	}
+	return entities
+}
+
+func manufacturer(b goblue.Brand) string {
+	switch b {
+	case goblue.BrandHyundai:
+		return "Hyundai"
+	case goblue.BrandKia:
+		return "Kia"
+	}
+	return string(b)
+}
@@ -0,0 +1,163 @@
+package goblue
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// QuotaPolicy decides what happens to calls exceeding the daily budget.
+type QuotaPolicy int
+
+const (
+	// QuotaRefuse fails calls exceeding the budget with ErrRateLimited.
+	QuotaRefuse QuotaPolicy = iota
+	// QuotaDelay holds back calls exceeding the budget until the next day.
+	QuotaDelay
+)
+
+// idPattern matches the vehicle and device ids contained in request paths.
+var idPattern = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+
+// QuotaUsage is a snapshot of the api calls made during the current day.
+type QuotaUsage struct {
+	Day         time.Time
+	Limit       int // 0 means unlimited
+	Used        int
+	PerEndpoint map[string]int
+}
+
+// Remaining returns the number of calls left for the day, or -1 if the
+// budget is unlimited.
+func (q QuotaUsage) Remaining() int {
+	if q.Limit == 0 {
+		return -1
+	}
+	if q.Used >= q.Limit {
+		return 0
+	}
+	return q.Limit - q.Used
+}
+
+type quota struct {
+	mu     sync.Mutex
+	limit  int
+	policy QuotaPolicy
+	now    func() time.Time
+
+	day         time.Time
+	used        int
+	perEndpoint map[string]int
+}
+
+func newQuota() *quota {
+	return &quota{now: time.Now, perEndpoint: map[string]int{}}
+}
+
+// rollover resets the counters once the day changed. q.mu must be held.
+func (q *quota) rollover() {
+	now := q.now()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if !day.Equal(q.day) {
+		q.day = day
+		q.used = 0
+		q.perEndpoint = map[string]int{}
+	}
+}
+
+// acquire books a call to the given endpoint. Depending on the policy it
+// fails or blocks once the budget is exhausted.
+func (q *quota) acquire(req *http.Request) error {
+	endpoint := endpointName(req)
+	for {
+		q.mu.Lock()
+		q.rollover()
+		if q.limit == 0 || q.used < q.limit {
+			q.used++
+			q.perEndpoint[endpoint]++
+			q.mu.Unlock()
+			return nil
+		}
+		next := q.day.AddDate(0, 0, 1)
+		policy := q.policy
+		q.mu.Unlock()
+
+		if policy != QuotaDelay {
+			return ErrRateLimited
+		}
+
+		t := time.NewTimer(next.Sub(q.now()))
+		select {
+		case <-t.C:
+		case <-req.Context().Done():
+			t.Stop()
+			return req.Context().Err()
+		}
+	}
+}
+
+// exhaust marks the budget of the day as used up, e.g. after the api
+// reported the limit as exceeded.
+func (q *quota) exhaust() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.rollover()
+	if q.limit != 0 && q.used < q.limit {
+		q.used = q.limit
+	}
+}
+
+func (q *quota) usage() QuotaUsage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.rollover()
+
+	perEndpoint := make(map[string]int, len(q.perEndpoint))
+	for k, v := range q.perEndpoint {
+		perEndpoint[k] = v
+	}
+	return QuotaUsage{
+		Day:         q.day,
+		Limit:       q.limit,
+		Used:        q.used,
+		PerEndpoint: perEndpoint,
+	}
+}
+
+// vehiclePath matches the paths of the vehicle endpoints of the api, the
+// only calls the api counts against the daily limit.
+var vehiclePath = regexp.MustCompile(`^/api/v[0-9]+/spa/vehicles(/|$)`)
+
+// quotaTransport counts the requests passed to the vehicle endpoints of the
+// api. Authentication calls and redirect hops are not counted.
+type quotaTransport struct {
+	quota     *quota
+	transport http.RoundTripper
+}
+
+func (t *quotaTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// http.Client sets Response on requests following a redirect
+	if req.Response != nil || !vehiclePath.MatchString(req.URL.Path) {
+		return t.transport.RoundTrip(req)
+	}
+
+	if err := t.quota.acquire(req); err != nil {
+		return nil, err
+	}
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// the api reports an exceeded limit by its resCode, not only by status
+	body, err := peekBody(&resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if errors.Is(checkResponse(resp.StatusCode, body), ErrRateLimited) {
+		t.quota.exhaust()
+	}
+	return resp, nil
+}
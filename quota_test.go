@@ -0,0 +1,61 @@
+package goblue
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestQuotaTransport(t *testing.T) {
+	body := `{"retCode": "S", "resCode": "0000", "resMsg": {}}`
+	rt := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}, nil
+	})
+
+	q := newQuota()
+	q.limit = 10
+	tr := &quotaTransport{quota: q, transport: rt}
+
+	call := func(path string, redirected bool) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, "https://api.example"+path, nil)
+		if redirected {
+			req.Response = &http.Response{}
+		}
+		resp, err := tr.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	call("/api/v1/user/oauth2/token", false)
+	call("/api/v1/spa/notifications/register", false)
+	call("/api/v1/spa/vehicles/123/status", true)
+	if used := q.usage().Used; used != 0 {
+		t.Fatalf("non-vehicle calls counted: %d", used)
+	}
+
+	call("/api/v1/spa/vehicles", false)
+	call("/api/v2/spa/vehicles/123/control/door", false)
+	if used := q.usage().Used; used != 2 {
+		t.Fatalf("used %d, want 2", used)
+	}
+
+	body = `{"retCode": "F", "resCode": "5091", "resMsg": "Exceeds number of requests"}`
+	call("/api/v1/spa/vehicles/123/status", false)
+	if u := q.usage(); u.Remaining() != 0 {
+		t.Fatalf("quota not exhausted by resCode 5091: %+v", u)
+	}
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
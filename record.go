@@ -0,0 +1,294 @@
+package goblue
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Exchange is a recorded request and its response. Fixture files hold one
+// exchange per line.
+type Exchange struct {
+	Method         string      `json:"method"`
+	Path           string      `json:"path"`
+	URL            string      `json:"url"`
+	RequestHeader  http.Header `json:"requestHeader,omitempty"`
+	RequestBody    string      `json:"requestBody,omitempty"`
+	StatusCode     int         `json:"statusCode"`
+	ResponseHeader http.Header `json:"responseHeader,omitempty"`
+	ResponseBody   string      `json:"responseBody,omitempty"`
+}
+
+func (e *Exchange) key() string {
+	return e.Method + " " + e.Path
+}
+
+// anonymized are the fields of api messages replaced by an Anonymizer, as
+// they identify the owner or locate the vehicle. Coordinates keep their type
+// to let replays decode them.
+var anonymized = map[string]interface{}{
+	"email":       redacted,
+	"username":    redacted,
+	"deviceid":    redacted,
+	"vehiclename": redacted,
+	"nickname":    redacted,
+	"lat":         0,
+	"lon":         0,
+	"alt":         0,
+	"latitude":    0,
+	"longitude":   0,
+	"altitude":    0,
+}
+
+// vehiclePathID matches the vehicle id in request paths.
+var vehiclePathID = regexp.MustCompile(`/vehicles/([^/]+)`)
+
+// NewRecordingTransport passes requests to the next transport and writes
+// each exchange as a json line to w. Credentials, tokens and stamps are
+// redacted before writing. Accounts, vins and vehicle ids are replaced by
+// pseudonyms, stable within the recording, and coordinates are zeroed.
+func NewRecordingTransport(w io.Writer, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &recordingTransport{w: w, transport: next, anon: NewAnonymizer()}
+}
+
+type recordingTransport struct {
+	mu        sync.Mutex // keeps the lines in the order of the exchanges
+	w         io.Writer
+	transport http.RoundTripper
+	anon      *Anonymizer
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := peekBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := peekBody(&resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// ids of the vehicles listed in a response become known before the
+	// path, so the response is anonymized first
+	respBody = t.anon.Anonymize(respBody)
+	u := t.anon.anonymizeURL(req.URL)
+	e := Exchange{
+		Method:         req.Method,
+		Path:           u.Path,
+		URL:            redactURL(u),
+		RequestHeader:  redactHeader(req.Header),
+		RequestBody:    redactBody(req.Header.Get("Content-Type"), t.anon.Anonymize(reqBody)),
+		StatusCode:     resp.StatusCode,
+		ResponseHeader: redactHeader(resp.Header),
+		ResponseBody:   redactBody(resp.Header.Get("Content-Type"), respBody),
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := t.w.Write(append(line, '\n')); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// Anonymizer replaces the values identifying the owner or locating the
+// vehicle in api messages. Vins and vehicle ids are replaced by pseudonyms,
+// stable for the lifetime of the Anonymizer, names and accounts are redacted
+// and coordinates are zeroed. It is safe for concurrent use.
+type Anonymizer struct {
+	mu         sync.Mutex
+	pseudonyms map[string]string
+	vins, ids  int
+}
+
+func NewAnonymizer() *Anonymizer {
+	return &Anonymizer{pseudonyms: map[string]string{}}
+}
+
+// Anonymize returns the anonymized copy of a json message. Other content
+// only has the vins and vehicle ids seen so far replaced.
+func (a *Anonymizer) Anonymize(body []byte) []byte {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return []byte(a.replaceKnown(string(body)))
+	}
+	a.learn(v)
+	out, err := json.Marshal(a.anonymizeJSON(v))
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+// anonymizeURL replaces the vehicle id of the path and all known ids in the
+// url.
+func (a *Anonymizer) anonymizeURL(u *url.URL) *url.URL {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if m := vehiclePathID.FindStringSubmatch(u.Path); m != nil {
+		a.pseudonym("vehicleid", m[1])
+	}
+	cp := *u
+	cp.Path = a.replaceKnown(u.Path)
+	cp.RawPath = ""
+	cp.RawQuery = a.replaceKnown(u.RawQuery)
+	return &cp
+}
+
+// pseudonym returns the stable replacement of a vin or vehicle id. a.mu must
+// be held.
+func (a *Anonymizer) pseudonym(key, value string) string {
+	if value == "" {
+		return value
+	}
+	if p, ok := a.pseudonyms[value]; ok {
+		return p
+	}
+	var p string
+	if key == "vin" {
+		a.vins++
+		p = fmt.Sprintf("VIN%014d", a.vins)
+	} else {
+		a.ids++
+		p = fmt.Sprintf("00000000-0000-0000-0000-%012d", a.ids)
+	}
+	a.pseudonyms[value] = p
+	return p
+}
+
+// replaceKnown replaces all vins and vehicle ids seen so far. a.mu must be
+// held.
+func (a *Anonymizer) replaceKnown(s string) string {
+	for value, p := range a.pseudonyms {
+		s = strings.ReplaceAll(s, value, p)
+	}
+	return s
+}
+
+// learn assigns pseudonyms to the vins and vehicle ids of a json value.
+// a.mu must be held.
+func (a *Anonymizer) learn(v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, val := range v {
+			key := strings.ToLower(k)
+			if s, ok := val.(string); ok && (key == "vin" || key == "vehicleid") {
+				a.pseudonym(key, s)
+				continue
+			}
+			a.learn(val)
+		}
+	case []interface{}:
+		for _, val := range v {
+			a.learn(val)
+		}
+	}
+}
+
+// anonymizeJSON replaces the identifying fields and all known ids of a json
+// value. a.mu must be held.
+func (a *Anonymizer) anonymizeJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, val := range v {
+			if r, ok := anonymized[strings.ToLower(k)]; ok {
+				v[k] = r
+				continue
+			}
+			v[k] = a.anonymizeJSON(val)
+		}
+	case []interface{}:
+		for i, val := range v {
+			v[i] = a.anonymizeJSON(val)
+		}
+	case string:
+		return a.replaceKnown(v)
+	}
+	return v
+}
+
+// NewReplayTransport serves the exchanges read from r, matching requests by
+// method and path. Exchanges recorded for the same request are served in
+// order, the last one is repeated once all others have been served.
+func NewReplayTransport(r io.Reader) (http.RoundTripper, error) {
+	t := &replayTransport{exchanges: map[string][]*Exchange{}}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		e := &Exchange{}
+		if err := json.Unmarshal(line, e); err != nil {
+			return nil, err
+		}
+		t.exchanges[e.key()] = append(t.exchanges[e.key()], e)
+	}
+	return t, scanner.Err()
+}
+
+type replayTransport struct {
+	mu        sync.Mutex
+	exchanges map[string][]*Exchange
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	key := req.Method + " " + req.URL.Path
+	t.mu.Lock()
+	exchanges := t.exchanges[key]
+	if len(exchanges) == 0 {
+		t.mu.Unlock()
+		return nil, fmt.Errorf("no recorded exchange for %s", key)
+	}
+	e := exchanges[0]
+	if len(exchanges) > 1 {
+		t.exchanges[key] = exchanges[1:]
+	}
+	t.mu.Unlock()
+
+	header := e.ResponseHeader.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode)),
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader([]byte(e.ResponseBody))),
+		ContentLength: int64(len(e.ResponseBody)),
+		Request:       req,
+	}, nil
+}
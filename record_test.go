@@ -0,0 +1,91 @@
+package goblue_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/frzifus/goblue"
+	"github.com/frzifus/goblue/bluelinktest"
+)
+
+func TestRecordingAnonymizesSession(t *testing.T) {
+	const (
+		id  = "5f0e4c2a-1b3d-4e5f-8a9b-0c1d2e3f4a5b"
+		vin = "KMHK381GFMU123456"
+	)
+	creds := bluelinktest.Credentials{Username: "driver@example.com", Password: "secret", PIN: "1234"}
+	srv := bluelinktest.NewServer(creds)
+	defer srv.Close()
+	srv.AddVehicle(bluelinktest.Vehicle{
+		ID: id, VIN: vin, Name: "Kona", Type: "EV",
+		State: bluelinktest.State{Location: goblue.Location{Latitude: 52.516275, Longitude: 13.377704}},
+	})
+
+	var buf bytes.Buffer
+	rec := goblue.NewRecordingTransport(&buf, srv.Client().Transport)
+	c, err := goblue.NewClient(srv.Config(goblue.BrandHyundai), goblue.WithBaseURL(srv.URL), goblue.WithTransport(rec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Authenticate(); err != nil {
+		t.Fatal(err)
+	}
+	vs, err := c.Vehicles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := vs[0].Status(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := vs[0].Position(); err != nil {
+		t.Fatal(err)
+	}
+
+	recording := buf.String()
+	for _, secret := range []string{id, vin, creds.Username, creds.Password, "52.516275", "13.377704"} {
+		if strings.Contains(recording, secret) {
+			t.Errorf("recording contains %q", secret)
+		}
+	}
+
+	replay, err := goblue.NewReplayTransport(strings.NewReader(recording))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err = goblue.NewClient(srv.Config(goblue.BrandHyundai), goblue.WithBaseURL(srv.URL), goblue.WithTransport(replay))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Authenticate(); err != nil {
+		t.Fatal(err)
+	}
+	if vs, err = c.Vehicles(); err != nil {
+		t.Fatal(err)
+	}
+	if vs[0].ID() == id || vs[0].VIN() == vin {
+		t.Errorf("replayed vehicle %s %s is not anonymized", vs[0].ID(), vs[0].VIN())
+	}
+	if _, err := vs[0].Status(); err != nil {
+		t.Errorf("replaying status of the anonymized vehicle: %v", err)
+	}
+}
+
+func TestAnonymizer(t *testing.T) {
+	a := goblue.NewAnonymizer()
+	got := string(a.Anonymize([]byte(`{"vehicles": [
+		{"vin": "KMHK381GFMU123456", "vehicleId": "5f0e4c2a", "nickname": "Kona", "gpsDetail": {"coord": {"lat": 52.5, "lon": 13.4, "alt": 34}}},
+		{"vin": "KNAXXXXXXXXX00002", "vehicleId": "77aa0e31", "vehicleName": "EV6"}
+	]}`)))
+	want := `{"vehicles":[` +
+		`{"gpsDetail":{"coord":{"alt":0,"lat":0,"lon":0}},"nickname":"REDACTED","vehicleId":"00000000-0000-0000-0000-000000000001","vin":"VIN00000000000001"},` +
+		`{"vehicleId":"00000000-0000-0000-0000-000000000002","vehicleName":"REDACTED","vin":"VIN00000000000002"}]}`
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+
+	// pseudonyms are stable, also outside of json
+	if got := string(a.Anonymize([]byte("status of 77aa0e31"))); got != "status of 00000000-0000-0000-0000-000000000002" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -1,5 +1,10 @@
 package goblue
 
+import (
+	"time"
+	_ "time/tzdata" // the api zone has to be known on systems lacking zoneinfo
+)
+
 type Region string
 
 const (
@@ -8,3 +13,13 @@ const (
 	RegionUS      Region = "us"
 	RegionCA      Region = "ca"
 )
+
+// apiLocation is the zone of the wall clock times the api reports without a
+// zone, like the time of a status. The EU api reports the time of Germany.
+var apiLocation = func() *time.Location {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}()
@@ -0,0 +1,315 @@
+package goblue
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const reportFlagSet = "1"
+
+// ECUStatus is the state of a single control unit as listed in the
+// breakdown of the monthly report.
+type ECUStatus struct {
+	Index  string
+	Status string
+}
+
+// MonthlyReport summarizes the usage and health of the vehicle over a month.
+type MonthlyReport struct {
+	Start           time.Time
+	End             time.Time
+	Distance        int // km
+	EngineStarts    int
+	DriveTime       time.Duration
+	IdleTime        time.Duration
+	TPMSWarning     bool
+	TirePressureLow bool
+	ECUs            []ECUStatus
+}
+
+// MonthlyReport returns the vehicle report of the given month.
+func (v *Vehicle) MonthlyReport(year int, month time.Month) (_ *MonthlyReport, err error) {
+	return v.MonthlyReportContext(context.Background(), year, month)
+}
+
+// MonthlyReportContext is like MonthlyReport, using ctx for the requests and
+// the parent span.
+func (v *Vehicle) MonthlyReportContext(ctx context.Context, year int, month time.Month) (_ *MonthlyReport, err error) {
+	ctx, op := v.startOperation(ctx, "goblue.Vehicle.MonthlyReport")
+	defer op.end(&err)
+
+	payload := map[string]interface{}{
+		"setRptMonth": fmt.Sprintf("%04d%02d", year, month),
+	}
+
+	msg := struct {
+		Monthlyreport struct {
+			Ifo struct {
+				Mvrmonthstart string `json:"mvrMonthStart"`
+				Mvrmonthend   string `json:"mvrMonthEnd"`
+			} `json:"ifo"`
+			Breakdown []struct {
+				Ecuidx    string `json:"ecuIdx"`
+				Ecustatus string `json:"ecuStatus"`
+			} `json:"breakdown"`
+			Driving struct {
+				Rundistance      int `json:"runDistance"`
+				Enginestartcount int `json:"engineStartCount"`
+				Engineidletime   int `json:"engineIdleTime"`
+				Engineontime     int `json:"engineOnTime"`
+			} `json:"driving"`
+			Vehiclestatus struct {
+				Tpms         string `json:"tpms"`
+				Tirepressure struct {
+					Tirepressurelampall string `json:"tirePressureLampAll"`
+				} `json:"tirePressure"`
+			} `json:"vehicleStatus"`
+		} `json:"monthlyReport"`
+	}{}
+
+	uri := fmt.Sprintf(v.auth.URI+v.endpoints.Report, v.id)
+	if err := v.doSpaRequest(ctx, http.MethodPost, uri, payload, &msg); err != nil {
+		return nil, err
+	}
+
+	r := msg.Monthlyreport
+	report := &MonthlyReport{
+		Distance:        r.Driving.Rundistance,
+		EngineStarts:    r.Driving.Enginestartcount,
+		DriveTime:       time.Duration(r.Driving.Engineontime) * time.Minute,
+		IdleTime:        time.Duration(r.Driving.Engineidletime) * time.Minute,
+		TPMSWarning:     r.Vehiclestatus.Tpms == reportFlagSet,
+		TirePressureLow: r.Vehiclestatus.Tirepressure.Tirepressurelampall == reportFlagSet,
+	}
+
+	if r.Ifo.Mvrmonthstart != "" {
+		if report.Start, err = time.ParseInLocation(tripDayLayout, r.Ifo.Mvrmonthstart, apiLocation); err != nil {
+			return nil, err
+		}
+	}
+	if r.Ifo.Mvrmonthend != "" {
+		if report.End, err = time.ParseInLocation(tripDayLayout, r.Ifo.Mvrmonthend, apiLocation); err != nil {
+			return nil, err
+		}
+	}
+	for _, b := range r.Breakdown {
+		report.ECUs = append(report.ECUs, ECUStatus{Index: b.Ecuidx, Status: b.Ecustatus})
+	}
+
+	return report, nil
+}
+
+// Diagnostics lists the warning lamps currently reported by the vehicle.
+type Diagnostics struct {
+	Airbag               bool
+	TirePressure         bool
+	TirePressureFL       bool
+	TirePressureFR       bool
+	TirePressureRL       bool
+	TirePressureRR       bool
+	BrakeFluid           bool
+	WasherFluid          bool
+	SmartKeyBattery      bool
+	HeadLamp             bool
+	HeadLampLeftLow      bool
+	HeadLampRightLow     bool
+	StopLampLeft         bool
+	StopLampRight        bool
+	TurnSignalLeftFront  bool
+	TurnSignalRightFront bool
+	TurnSignalLeftRear   bool
+	TurnSignalRightRear  bool
+}
+
+// Warnings reports whether any of the warning lamps is on.
+func (d *Diagnostics) Warnings() bool {
+	return d.Airbag ||
+		d.TirePressure || d.TirePressureFL || d.TirePressureFR ||
+		d.TirePressureRL || d.TirePressureRR ||
+		d.BrakeFluid || d.WasherFluid || d.SmartKeyBattery ||
+		d.HeadLamp || d.HeadLampLeftLow || d.HeadLampRightLow ||
+		d.StopLampLeft || d.StopLampRight ||
+		d.TurnSignalLeftFront || d.TurnSignalRightFront ||
+		d.TurnSignalLeftRear || d.TurnSignalRightRear
+}
+
+// Diagnostics returns the vehicle health as reported by the status endpoint.
+func (v *Vehicle) Diagnostics() (_ *Diagnostics, err error) {
+	return v.DiagnosticsContext(context.Background())
+}
+
+// DiagnosticsContext is like Diagnostics, using ctx for the requests and the
+// parent span.
+func (v *Vehicle) DiagnosticsContext(ctx context.Context) (_ *Diagnostics, err error) {
+	ctx, op := v.startOperation(ctx, "goblue.Vehicle.Diagnostics")
+	defer op.end(&err)
+
+	if v.Capabilities().Has(CapabilityCCS2) {
+		msg := ccs2DiagnosticsResponse{}
+		uri := fmt.Sprintf(v.auth.URI+v.endpoints.CCS2Status, v.id)
+		if err := v.doSpaRequest(ctx, http.MethodGet, uri, nil, &msg); err != nil {
+			return nil, err
+		}
+		return msg.diagnostics(), nil
+	}
+
+	msg := diagnosticsResponse{}
+	uri := fmt.Sprintf(v.auth.URI+v.endpoints.Status, v.id)
+	if err := v.doSpaRequest(ctx, http.MethodGet, uri, nil, &msg); err != nil {
+		return nil, err
+	}
+	return msg.diagnostics(), nil
+}
+
+// diagnosticsResponse holds the warning lamps of the classic status.
+type diagnosticsResponse struct {
+	Airbagwarning    bool `json:"airbagWarning"`
+	Tirepressurelamp struct {
+		Tirepressurelampall int `json:"tirePressureLampAll"`
+		Tirepressurelampfl  int `json:"tirePressureLampFL"`
+		Tirepressurelampfr  int `json:"tirePressureLampFR"`
+		Tirepressurelamprl  int `json:"tirePressureLampRL"`
+		Tirepressurelamprr  int `json:"tirePressureLampRR"`
+	} `json:"tirePressureLamp"`
+	Breakoilstatus         bool `json:"breakOilStatus"`
+	Washerfluidstatus      bool `json:"washerFluidStatus"`
+	Smartkeybatterywarning bool `json:"smartKeyBatteryWarning"`
+	Lampwirestatus         struct {
+		Headlamp struct {
+			Headlampstatus bool `json:"headLampStatus"`
+			Leftlowlamp    bool `json:"leftLowLamp"`
+			Rightlowlamp   bool `json:"rightLowLamp"`
+		} `json:"headLamp"`
+		Stoplamp struct {
+			Leftlamp  bool `json:"leftLamp"`
+			Rightlamp bool `json:"rightLamp"`
+		} `json:"stopLamp"`
+		Turnsignallamp struct {
+			Leftfrontlamp  bool `json:"leftFrontLamp"`
+			Rightfrontlamp bool `json:"rightFrontLamp"`
+			Leftrearlamp   bool `json:"leftRearLamp"`
+			Rightrearlamp  bool `json:"rightRearLamp"`
+		} `json:"turnSignalLamp"`
+	} `json:"lampWireStatus"`
+}
+
+func (m *diagnosticsResponse) diagnostics() *Diagnostics {
+	tires, lamps := m.Tirepressurelamp, m.Lampwirestatus
+	return &Diagnostics{
+		Airbag:               m.Airbagwarning,
+		TirePressure:         tires.Tirepressurelampall != 0,
+		TirePressureFL:       tires.Tirepressurelampfl != 0,
+		TirePressureFR:       tires.Tirepressurelampfr != 0,
+		TirePressureRL:       tires.Tirepressurelamprl != 0,
+		TirePressureRR:       tires.Tirepressurelamprr != 0,
+		BrakeFluid:           m.Breakoilstatus,
+		WasherFluid:          m.Washerfluidstatus,
+		SmartKeyBattery:      m.Smartkeybatterywarning,
+		HeadLamp:             lamps.Headlamp.Headlampstatus,
+		HeadLampLeftLow:      lamps.Headlamp.Leftlowlamp,
+		HeadLampRightLow:     lamps.Headlamp.Rightlowlamp,
+		StopLampLeft:         lamps.Stoplamp.Leftlamp,
+		StopLampRight:        lamps.Stoplamp.Rightlamp,
+		TurnSignalLeftFront:  lamps.Turnsignallamp.Leftfrontlamp,
+		TurnSignalRightFront: lamps.Turnsignallamp.Rightfrontlamp,
+		TurnSignalLeftRear:   lamps.Turnsignallamp.Leftrearlamp,
+		TurnSignalRightRear:  lamps.Turnsignallamp.Rightrearlamp,
+	}
+}
+
+type ccs2Warning struct {
+	Warning int `json:"Warning"`
+}
+
+type ccs2Tire struct {
+	Tire struct {
+		Pressurelow int `json:"PressureLow"`
+	} `json:"Tire"`
+}
+
+type ccs2Lamp struct {
+	Low        ccs2Warning `json:"Low"`
+	Stoplamp   ccs2Warning `json:"StopLamp"`
+	Turnsignal ccs2Warning `json:"TurnSignal"`
+}
+
+// ccs2DiagnosticsResponse holds the warnings of the ccs2 status.
+type ccs2DiagnosticsResponse struct {
+	State struct {
+		Vehicle struct {
+			Cabin struct {
+				Airbag ccs2Warning `json:"Airbag"`
+			} `json:"Cabin"`
+			Chassis struct {
+				Axle struct {
+					Row1 struct {
+						Left  ccs2Tire `json:"Left"`
+						Right ccs2Tire `json:"Right"`
+					} `json:"Row1"`
+					Row2 struct {
+						Left  ccs2Tire `json:"Left"`
+						Right ccs2Tire `json:"Right"`
+					} `json:"Row2"`
+					Tire struct {
+						Pressurelow int `json:"PressureLow"`
+					} `json:"Tire"`
+				} `json:"Axle"`
+				Brake struct {
+					Fluid ccs2Warning `json:"Fluid"`
+				} `json:"Brake"`
+			} `json:"Chassis"`
+			Body struct {
+				Windshield struct {
+					Front struct {
+						Washerfluid struct {
+							Levellow int `json:"LevelLow"`
+						} `json:"WasherFluid"`
+					} `json:"Front"`
+				} `json:"Windshield"`
+				Lights struct {
+					Front struct {
+						Left  ccs2Lamp `json:"Left"`
+						Right ccs2Lamp `json:"Right"`
+					} `json:"Front"`
+					Rear struct {
+						Left  ccs2Lamp `json:"Left"`
+						Right ccs2Lamp `json:"Right"`
+					} `json:"Rear"`
+				} `json:"Lights"`
+			} `json:"Body"`
+			Electronics struct {
+				Fob struct {
+					Lowbattery int `json:"LowBattery"`
+				} `json:"FOB"`
+			} `json:"Electronics"`
+		} `json:"Vehicle"`
+	} `json:"state"`
+}
+
+func (m *ccs2DiagnosticsResponse) diagnostics() *Diagnostics {
+	vs := m.State.Vehicle
+	axle, lights := vs.Chassis.Axle, vs.Body.Lights
+	d := &Diagnostics{
+		Airbag:               vs.Cabin.Airbag.Warning != 0,
+		TirePressure:         axle.Tire.Pressurelow != 0,
+		TirePressureFL:       axle.Row1.Left.Tire.Pressurelow != 0,
+		TirePressureFR:       axle.Row1.Right.Tire.Pressurelow != 0,
+		TirePressureRL:       axle.Row2.Left.Tire.Pressurelow != 0,
+		TirePressureRR:       axle.Row2.Right.Tire.Pressurelow != 0,
+		BrakeFluid:           vs.Chassis.Brake.Fluid.Warning != 0,
+		WasherFluid:          vs.Body.Windshield.Front.Washerfluid.Levellow != 0,
+		SmartKeyBattery:      vs.Electronics.Fob.Lowbattery != 0,
+		HeadLampLeftLow:      lights.Front.Left.Low.Warning != 0,
+		HeadLampRightLow:     lights.Front.Right.Low.Warning != 0,
+		StopLampLeft:         lights.Rear.Left.Stoplamp.Warning != 0,
+		StopLampRight:        lights.Rear.Right.Stoplamp.Warning != 0,
+		TurnSignalLeftFront:  lights.Front.Left.Turnsignal.Warning != 0,
+		TurnSignalRightFront: lights.Front.Right.Turnsignal.Warning != 0,
+		TurnSignalLeftRear:   lights.Rear.Left.Turnsignal.Warning != 0,
+		TurnSignalRightRear:  lights.Rear.Right.Turnsignal.Warning != 0,
+	}
+	d.HeadLamp = d.HeadLampLeftLow || d.HeadLampRightLow
+	return d
+}
@@ -0,0 +1,87 @@
+package goblue
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiagnostics(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		msg  interface{ diagnostics() *Diagnostics }
+		want Diagnostics
+	}{
+		{
+			name: "classic",
+			body: `{"airbagWarning": true, "tirePressureLamp": {"tirePressureLampAll": 1, "tirePressureLampRR": 1},
+				"washerFluidStatus": true, "lampWireStatus": {"stopLamp": {"leftLamp": true}}}`,
+			msg:  &diagnosticsResponse{},
+			want: Diagnostics{Airbag: true, TirePressure: true, TirePressureRR: true, WasherFluid: true, StopLampLeft: true},
+		},
+		{
+			name: "ccs2",
+			body: `{"state": {"Vehicle": {
+				"Cabin": {"Airbag": {"Warning": 1}},
+				"Chassis": {"Axle": {"Tire": {"PressureLow": 1}, "Row1": {"Left": {"Tire": {"PressureLow": 1}}}},
+					"Brake": {"Fluid": {"Warning": 1}}},
+				"Body": {"Lights": {"Front": {"Right": {"Low": {"Warning": 1}}}}},
+				"Electronics": {"FOB": {"LowBattery": 1}}}}}`,
+			msg: &ccs2DiagnosticsResponse{},
+			want: Diagnostics{Airbag: true, TirePressure: true, TirePressureFL: true, BrakeFluid: true,
+				HeadLamp: true, HeadLampRightLow: true, SmartKeyBattery: true},
+		},
+		{
+			name: "no warnings",
+			body: `{"state": {"Vehicle": {}}}`,
+			msg:  &ccs2DiagnosticsResponse{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := json.Unmarshal([]byte(tt.body), tt.msg); err != nil {
+				t.Fatal(err)
+			}
+			got := tt.msg.diagnostics()
+			if *got != tt.want {
+				t.Errorf("got %+v, want %+v", *got, tt.want)
+			}
+			if got.Warnings() != (tt.want != Diagnostics{}) {
+				t.Errorf("Warnings() = %v", got.Warnings())
+			}
+		})
+	}
+}
+
+func TestMonthlyReport(t *testing.T) {
+	v := newFixtureVehicle(t, "GN", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"retCode": "S", "resCode": "0000", "resMsg": {"monthlyReport": {
+			"ifo": {"mvrMonthStart": "20240301", "mvrMonthEnd": "20240331"},
+			"breakdown": [{"ecuIdx": "1", "ecuStatus": "0"}],
+			"driving": {"runDistance": 812, "engineStartCount": 41, "engineIdleTime": 93, "engineOnTime": 1104},
+			"vehicleStatus": {"tpms": "0", "tirePressure": {"tirePressureLampAll": "1"}}
+		}}}`))
+	})
+
+	report, err := v.MonthlyReport(2024, time.March)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &MonthlyReport{
+		Start:           time.Date(2024, 3, 1, 0, 0, 0, 0, apiLocation),
+		End:             time.Date(2024, 3, 31, 0, 0, 0, 0, apiLocation),
+		Distance:        812,
+		EngineStarts:    41,
+		DriveTime:       1104 * time.Minute,
+		IdleTime:        93 * time.Minute,
+		TirePressureLow: true,
+		ECUs:            []ECUStatus{{Index: "1", Status: "0"}},
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("got %+v\nwant %+v", report, want)
+	}
+}
@@ -0,0 +1,74 @@
+package rest
+
+import (
+	"sync"
+	"time"
+)
+
+// cache holds api responses for a fixed time. Concurrent requests of the
+// same key share a single fetch, failed fetches are not cached.
+type cache struct {
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+type cacheEntry struct {
+	ready   chan struct{}
+	value   interface{}
+	err     error
+	fetched time.Time
+	expires time.Time
+}
+
+func newCache() *cache {
+	return &cache{now: time.Now, entries: map[string]*cacheEntry{}}
+}
+
+// get returns the cached value of key, or fetches and caches it for ttl.
+// The time the value was fetched is returned along with it.
+func (c *cache) get(key string, ttl time.Duration, fetch func() (interface{}, error)) (interface{}, time.Time, error) {
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	if ok {
+		select {
+		case <-e.ready:
+			if c.now().Before(e.expires) {
+				c.mu.Unlock()
+				return e.value, e.fetched, nil
+			}
+			ok = false
+		default:
+			// another request is fetching the value
+		}
+	}
+	if !ok {
+		e = &cacheEntry{ready: make(chan struct{})}
+		c.entries[key] = e
+		c.mu.Unlock()
+
+		e.value, e.err = fetch()
+		e.fetched = c.now()
+		e.expires = e.fetched.Add(ttl)
+
+		c.mu.Lock()
+		if e.err != nil && c.entries[key] == e {
+			delete(c.entries, key)
+		}
+		close(e.ready)
+		c.mu.Unlock()
+		return e.value, e.fetched, e.err
+	}
+	c.mu.Unlock()
+
+	<-e.ready
+	return e.value, e.fetched, e.err
+}
+
+// invalidate drops the cached value of key.
+func (c *cache) invalidate(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
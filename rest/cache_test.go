@@ -0,0 +1,113 @@
+package rest
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock is a settable time source for the cache.
+type fakeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+func newTestCache() (*cache, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+	c := newCache()
+	c.now = clock.now
+	return c, clock
+}
+
+func TestCacheExpiry(t *testing.T) {
+	c, clock := newTestCache()
+	var fetches int
+	fetch := func() (interface{}, error) {
+		fetches++
+		return fetches, nil
+	}
+
+	start := clock.now()
+	if v, fetched, err := c.get("key", time.Minute, fetch); err != nil || v != 1 || !fetched.Equal(start) {
+		t.Fatalf("first get: %v, %v, %v", v, fetched, err)
+	}
+	clock.advance(59 * time.Second)
+	if v, fetched, _ := c.get("key", time.Minute, fetch); v != 1 || !fetched.Equal(start) {
+		t.Errorf("get before expiry: got %v fetched at %v, want the cached value", v, fetched)
+	}
+	clock.advance(time.Second)
+	if v, _, _ := c.get("key", time.Minute, fetch); v != 2 {
+		t.Errorf("get after expiry: got %v, want a fresh value", v)
+	}
+
+	c.invalidate("key")
+	if v, _, _ := c.get("key", time.Minute, fetch); v != 3 {
+		t.Errorf("get after invalidate: got %v, want a fresh value", v)
+	}
+}
+
+func TestCacheErrorsAreNotCached(t *testing.T) {
+	c, _ := newTestCache()
+	errFetch := errors.New("fetch failed")
+	if _, _, err := c.get("key", time.Minute, func() (interface{}, error) { return nil, errFetch }); err != errFetch {
+		t.Fatalf("got %v, want the fetch error", err)
+	}
+	v, _, err := c.get("key", time.Minute, func() (interface{}, error) { return "value", nil })
+	if err != nil || v != "value" {
+		t.Errorf("get after failure: got %v, %v, want a fresh value", v, err)
+	}
+}
+
+func TestCacheConcurrentGetsShareFetch(t *testing.T) {
+	c, _ := newTestCache()
+	var fetches int32
+	started, release := make(chan struct{}), make(chan struct{})
+	fetch := func() (interface{}, error) {
+		if atomic.AddInt32(&fetches, 1) == 1 {
+			close(started)
+		}
+		<-release
+		return "value", nil
+	}
+
+	const n = 8
+	var wg sync.WaitGroup
+	results := make([]interface{}, n)
+	get := func(i int) {
+		defer wg.Done()
+		results[i], _, _ = c.get("key", time.Minute, fetch)
+	}
+	wg.Add(n)
+	go get(0)
+	<-started
+	for i := 1; i < n; i++ {
+		go get(i)
+	}
+	// give the others a chance to queue up behind the pending fetch
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if fetches != 1 {
+		t.Errorf("got %d fetches, want 1", fetches)
+	}
+	for i, v := range results {
+		if v != "value" {
+			t.Errorf("get %d: got %v", i, v)
+		}
+	}
+}
@@ -0,0 +1,396 @@
+// Package rest serves the vehicles of an account as a local JSON api for
+// tools that can not use goblue directly.
+//
+//	GET  /vehicles
+//	GET  /vehicles/{vin}/status
+//	GET  /vehicles/{vin}/location
+//	POST /vehicles/{vin}/lock
+//	POST /vehicles/{vin}/unlock
+//	POST /vehicles/{vin}/climate/start  optional body {"temperature": 21, "defrost": false, "heating": false}
+//	POST /vehicles/{vin}/climate/stop
+//	POST /vehicles/{vin}/charge/start
+//	POST /vehicles/{vin}/charge/stop
+//	GET  /quota
+//
+// Responses of the Bluelink api are cached to protect the daily request
+// limit, the Age header tells how old a response is. A successful command
+// drops the cached status of the vehicle. Requests are authorized by an api
+// key sent as "Authorization: Bearer <key>" or "X-API-Key: <key>".
+package rest
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/frzifus/goblue"
+)
+
+const (
+	// DefaultStatusTTL is the time the status and location of a vehicle are
+	// cached.
+	DefaultStatusTTL = 5 * time.Minute
+	// DefaultVehiclesTTL is the time the vehicles of the account are cached.
+	DefaultVehiclesTTL = time.Hour
+
+	maxBodySize = 1 << 12
+)
+
+type Option func(*Handler)
+
+// WithAPIKeys sets the keys accepted by the handler. Without keys all
+// requests are allowed.
+func WithAPIKeys(keys ...string) Option {
+	return func(h *Handler) {
+		h.keys = append(h.keys, keys...)
+	}
+}
+
+// WithStatusTTL sets the time the status and location of a vehicle are
+// cached.
+func WithStatusTTL(d time.Duration) Option {
+	return func(h *Handler) {
+		h.statusTTL = d
+	}
+}
+
+// WithVehiclesTTL sets the time the vehicles of the account are cached.
+func WithVehiclesTTL(d time.Duration) Option {
+	return func(h *Handler) {
+		h.vehiclesTTL = d
+	}
+}
+
+// WithLogger logs failed requests, api failures at error level.
+func WithLogger(l goblue.Logger) Option {
+	return func(h *Handler) {
+		h.logger = l
+	}
+}
+
+// Handler is an http.Handler serving the vehicles of a Client. The client
+// is authenticated on demand and again once its session expired.
+type Handler struct {
+	client      *goblue.Client
+	keys        []string
+	statusTTL   time.Duration
+	vehiclesTTL time.Duration
+	logger      goblue.Logger
+
+	cache *cache
+}
+
+// NewHandler returns a Handler backed by the client.
+func NewHandler(c *goblue.Client, opts ...Option) *Handler {
+	h := &Handler{
+		client:      c,
+		statusTTL:   DefaultStatusTTL,
+		vehiclesTTL: DefaultVehiclesTTL,
+		logger:      nopLogger{},
+		cache:       newCache(),
+	}
+	for _, o := range opts {
+		o(h)
+	}
+	return h
+}
+
+// Vehicle is the json representation of a vehicle.
+type Vehicle struct {
+	VIN          string       `json:"vin"`
+	ID           string       `json:"id"`
+	Name         string       `json:"name"`
+	Type         string       `json:"type"`
+	Brand        goblue.Brand `json:"brand"`
+	Model        string       `json:"model"`
+	Capabilities string       `json:"capabilities"`
+}
+
+// CommandResult is the response of a successful command.
+type CommandResult struct {
+	VIN     string `json:"vin"`
+	Command string `json:"command"`
+}
+
+// Quota is the json representation of the api calls made today, see
+// goblue.QuotaUsage.
+type Quota struct {
+	Day         time.Time      `json:"day"`
+	Limit       int            `json:"limit"`
+	Used        int            `json:"used"`
+	Remaining   int            `json:"remaining"`
+	PerEndpoint map[string]int `json:"perEndpoint"`
+}
+
+// Error is the response of a failed request.
+type Error struct {
+	Error string `json:"error"`
+}
+
+// commands maps the command paths below /vehicles/{vin}/ to the vehicle
+// methods sending them. Commands are cancelled once the client is gone.
+var commands = map[string]func(*goblue.Vehicle, *http.Request) error{
+	"lock":   func(v *goblue.Vehicle, r *http.Request) error { return v.LockContext(r.Context()) },
+	"unlock": func(v *goblue.Vehicle, r *http.Request) error { return v.UnlockContext(r.Context()) },
+	"climate/start": func(v *goblue.Vehicle, r *http.Request) error {
+		opts := goblue.StartOptions{Temperature: goblue.DefaultTemperature}
+		if err := decodeBody(r, &opts); err != nil {
+			return err
+		}
+		return v.StartContext(r.Context(), opts)
+	},
+	"climate/stop": func(v *goblue.Vehicle, r *http.Request) error { return v.StopContext(r.Context()) },
+	"charge/start": func(v *goblue.Vehicle, r *http.Request) error { return v.StartChargeContext(r.Context()) },
+	"charge/stop":  func(v *goblue.Vehicle, r *http.Request) error { return v.StopChargeContext(r.Context()) },
+}
+
+// errBadRequest marks errors caused by the request.
+var errBadRequest = errors.New("bad request")
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="goblue"`)
+		h.writeError(w, r, http.StatusUnauthorized, errors.New("invalid api key"))
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "vehicles":
+		if h.allow(w, r, http.MethodGet) {
+			h.vehicles(w, r)
+		}
+	case len(parts) == 1 && parts[0] == "quota":
+		if h.allow(w, r, http.MethodGet) {
+			h.quota(w)
+		}
+	case len(parts) == 3 && parts[0] == "vehicles" && parts[2] == "status":
+		if h.allow(w, r, http.MethodGet) {
+			h.status(w, r, parts[1])
+		}
+	case len(parts) == 3 && parts[0] == "vehicles" && parts[2] == "location":
+		if h.allow(w, r, http.MethodGet) {
+			h.location(w, r, parts[1])
+		}
+	case len(parts) >= 3 && parts[0] == "vehicles" && commands[strings.Join(parts[2:], "/")] != nil:
+		if h.allow(w, r, http.MethodPost) {
+			h.command(w, r, parts[1], strings.Join(parts[2:], "/"))
+		}
+	default:
+		h.writeError(w, r, http.StatusNotFound, errors.New("not found"))
+	}
+}
+
+func (h *Handler) vehicles(w http.ResponseWriter, r *http.Request) {
+	vs, fetched, err := h.fetchVehicles()
+	if err != nil {
+		h.writeAPIError(w, r, err)
+		return
+	}
+
+	results := []Vehicle{}
+	for _, v := range vs {
+		results = append(results, Vehicle{
+			VIN:          v.VIN(),
+			ID:           v.ID(),
+			Name:         v.Name(),
+			Type:         v.Type(),
+			Brand:        v.Brand(),
+			Model:        v.Info().ModelName,
+			Capabilities: v.Capabilities().String(),
+		})
+	}
+	h.writeCached(w, fetched, h.vehiclesTTL, results)
+}
+
+func (h *Handler) quota(w http.ResponseWriter) {
+	q := h.client.Quota()
+	h.writeJSON(w, http.StatusOK, Quota{
+		Day:         q.Day,
+		Limit:       q.Limit,
+		Used:        q.Used,
+		Remaining:   q.Remaining(),
+		PerEndpoint: q.PerEndpoint,
+	})
+}
+
+func (h *Handler) status(w http.ResponseWriter, r *http.Request, vin string) {
+	h.cached(w, r, vin, "status", func(v *goblue.Vehicle) (interface{}, error) {
+		return v.Status()
+	})
+}
+
+func (h *Handler) location(w http.ResponseWriter, r *http.Request, vin string) {
+	h.cached(w, r, vin, "location", func(v *goblue.Vehicle) (interface{}, error) {
+		return v.Position()
+	})
+}
+
+// cached writes the cached value of a vehicle or fetches it.
+func (h *Handler) cached(w http.ResponseWriter, r *http.Request, vin, name string, fetch func(*goblue.Vehicle) (interface{}, error)) {
+	v, err := h.vehicle(vin)
+	if err != nil {
+		h.writeAPIError(w, r, err)
+		return
+	}
+
+	value, fetched, err := h.cache.get(v.VIN()+"/"+name, h.statusTTL, func() (interface{}, error) {
+		// the fetch is shared by concurrent requests, none of them may
+		// cancel it
+		var value interface{}
+		err := h.client.Reauthenticate(context.Background(), func() (err error) {
+			value, err = fetch(v)
+			return err
+		})
+		return value, err
+	})
+	if err != nil {
+		h.writeAPIError(w, r, err)
+		return
+	}
+	h.writeCached(w, fetched, h.statusTTL, value)
+}
+
+func (h *Handler) command(w http.ResponseWriter, r *http.Request, vin, name string) {
+	v, err := h.vehicle(vin)
+	if err != nil {
+		h.writeAPIError(w, r, err)
+		return
+	}
+
+	if err := h.client.Reauthenticate(r.Context(), func() error { return commands[name](v, r) }); err != nil {
+		h.writeAPIError(w, r, err)
+		return
+	}
+	h.cache.invalidate(v.VIN() + "/status")
+
+	h.writeJSON(w, http.StatusOK, CommandResult{VIN: v.VIN(), Command: strings.Replace(name, "/", " ", 1)})
+}
+
+// fetchVehicles returns the cached vehicles of the account.
+func (h *Handler) fetchVehicles() ([]*goblue.Vehicle, time.Time, error) {
+	value, fetched, err := h.cache.get("vehicles", h.vehiclesTTL, func() (interface{}, error) {
+		var vs []*goblue.Vehicle
+		err := h.client.Reauthenticate(context.Background(), func() (err error) {
+			vs, err = h.client.Vehicles()
+			return err
+		})
+		return vs, err
+	})
+	if err != nil {
+		return nil, fetched, err
+	}
+	return value.([]*goblue.Vehicle), fetched, nil
+}
+
+func (h *Handler) vehicle(vin string) (*goblue.Vehicle, error) {
+	vs, _, err := h.fetchVehicles()
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range vs {
+		if strings.EqualFold(v.VIN(), vin) {
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("vehicle %s: %w", vin, goblue.ErrNoVehicleFound)
+}
+
+func (h *Handler) authorized(r *http.Request) bool {
+	if len(h.keys) == 0 {
+		return true
+	}
+	key := r.Header.Get("X-API-Key")
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		key = strings.TrimPrefix(auth, "Bearer ")
+	}
+	if key == "" {
+		return false
+	}
+
+	ok := 0
+	for _, k := range h.keys {
+		ok |= subtle.ConstantTimeCompare([]byte(k), []byte(key))
+	}
+	return ok == 1
+}
+
+// allow reports whether the request uses the method, otherwise it answers
+// with 405.
+func (h *Handler) allow(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	h.writeError(w, r, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+	return false
+}
+
+// writeCached writes a cached value along with its age.
+func (h *Handler) writeCached(w http.ResponseWriter, fetched time.Time, ttl time.Duration, v interface{}) {
+	age := int(h.cache.now().Sub(fetched).Seconds())
+	if age < 0 {
+		age = 0
+	}
+	maxAge := int(ttl.Seconds()) - age
+	if maxAge < 0 {
+		maxAge = 0
+	}
+	w.Header().Set("Age", strconv.Itoa(age))
+	w.Header().Set("Cache-Control", "private, max-age="+strconv.Itoa(maxAge))
+	h.writeJSON(w, http.StatusOK, v)
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(v)
+}
+
+func (h *Handler) writeError(w http.ResponseWriter, r *http.Request, statusCode int, err error) {
+	log := h.logger.Debug
+	if statusCode >= http.StatusInternalServerError {
+		log = h.logger.Error
+	}
+	log("request failed", "method", r.Method, "path", r.URL.Path, "status", statusCode, "err", err)
+	h.writeJSON(w, statusCode, Error{Error: err.Error()})
+}
+
+// writeAPIError answers with the status code matching a goblue error.
+func (h *Handler) writeAPIError(w http.ResponseWriter, r *http.Request, err error) {
+	statusCode := http.StatusBadGateway
+	switch {
+	case errors.Is(err, errBadRequest), errors.Is(err, goblue.ErrInvalidTemperature):
+		statusCode = http.StatusBadRequest
+	case errors.Is(err, goblue.ErrNoVehicleFound):
+		statusCode = http.StatusNotFound
+	case errors.Is(err, goblue.ErrUnsupported):
+		statusCode = http.StatusNotImplemented
+	case errors.Is(err, goblue.ErrRateLimited):
+		statusCode = http.StatusTooManyRequests
+	case errors.Is(err, goblue.ErrCommandTimeout), errors.Is(err, goblue.ErrVehicleAsleep):
+		statusCode = http.StatusGatewayTimeout
+	}
+	h.writeError(w, r, statusCode, err)
+}
+
+// decodeBody decodes an optional json body into v.
+func decodeBody(r *http.Request, v interface{}) error {
+	err := json.NewDecoder(io.LimitReader(r.Body, maxBodySize)).Decode(v)
+	if err == nil || err == io.EOF {
+		return nil
+	}
+	return fmt.Errorf("%w: %v", errBadRequest, err)
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+func (nopLogger) Error(string, ...interface{}) {}
@@ -0,0 +1,251 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/frzifus/goblue"
+	"github.com/frzifus/goblue/bluelinktest"
+)
+
+const (
+	testID  = "00000000-0000-0000-0000-0000000000a1"
+	testVIN = "KMHTEST0000000001"
+	testKey = "key-a"
+)
+
+// testHandler is a Handler backed by a fake api with one electric vehicle.
+type testHandler struct {
+	*Handler
+	srv   *bluelinktest.Server
+	clock *fakeClock
+	calls int32 // api calls, set atomically
+	// records receives a value whenever the command records are polled
+	records chan struct{}
+}
+
+func newTestHandler(t *testing.T) *testHandler {
+	t.Helper()
+	th := &testHandler{records: make(chan struct{}, 1)}
+	th.srv = bluelinktest.NewServer(bluelinktest.Credentials{Username: "user", Password: "secret", PIN: "1234"})
+	t.Cleanup(th.srv.Close)
+	th.srv.AddVehicle(bluelinktest.Vehicle{
+		ID:    testID,
+		VIN:   testVIN,
+		Name:  "Kona",
+		Type:  "EV",
+		State: bluelinktest.State{Locked: true, SoC: 80, UpdatedAt: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)},
+	})
+
+	counting := func(next goblue.HttpClient) goblue.HttpClient {
+		return goblue.HttpClientFunc(func(req *http.Request) (*http.Response, error) {
+			atomic.AddInt32(&th.calls, 1)
+			if strings.HasSuffix(req.URL.Path, "/records") {
+				select {
+				case th.records <- struct{}{}:
+				default:
+				}
+			}
+			return next.Do(req)
+		})
+	}
+	opts := append(th.srv.ClientOptions(), goblue.WithMiddleware(counting))
+	c, err := goblue.NewClient(th.srv.Config(goblue.BrandHyundai), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	th.Handler = NewHandler(c, WithAPIKeys(testKey, "key-b"))
+	th.cache, th.clock = newTestCache()
+	return th
+}
+
+func (th *testHandler) apiCalls() int {
+	return int(atomic.LoadInt32(&th.calls))
+}
+
+// do serves a request authorized by the test key.
+func (th *testHandler) do(method, path string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, nil)
+	r.Header.Set("Authorization", "Bearer "+testKey)
+	w := httptest.NewRecorder()
+	th.ServeHTTP(w, r)
+	return w
+}
+
+func TestHandlerAuthorization(t *testing.T) {
+	th := newTestHandler(t)
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		want   int
+	}{
+		{"missing key", "", "", http.StatusUnauthorized},
+		{"wrong bearer key", "Authorization", "Bearer key-c", http.StatusUnauthorized},
+		{"wrong header key", "X-API-Key", "key-c", http.StatusUnauthorized},
+		{"key prefix", "Authorization", "Bearer key", http.StatusUnauthorized},
+		{"bearer key", "Authorization", "Bearer key-a", http.StatusOK},
+		{"header key", "X-API-Key", "key-b", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/quota", nil)
+			if tt.header != "" {
+				r.Header.Set(tt.header, tt.value)
+			}
+			w := httptest.NewRecorder()
+			th.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("got status %d, want %d", w.Code, tt.want)
+			}
+			if tt.want == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("WWW-Authenticate header missing")
+			}
+		})
+	}
+}
+
+func TestHandlerRouting(t *testing.T) {
+	th := newTestHandler(t)
+
+	tests := []struct {
+		method, path string
+		want         int
+		allow        string
+	}{
+		{http.MethodGet, "/vehicles", http.StatusOK, ""},
+		{http.MethodGet, "/vehicles/", http.StatusOK, ""},
+		{http.MethodGet, "/quota", http.StatusOK, ""},
+		{http.MethodGet, "/vehicles/" + testVIN + "/status", http.StatusOK, ""},
+		{http.MethodGet, "/vehicles/" + strings.ToLower(testVIN) + "/location", http.StatusOK, ""},
+		{http.MethodPost, "/vehicles/" + testVIN + "/climate/stop", http.StatusOK, ""},
+		{http.MethodPost, "/vehicles", http.StatusMethodNotAllowed, http.MethodGet},
+		{http.MethodDelete, "/vehicles/" + testVIN + "/status", http.StatusMethodNotAllowed, http.MethodGet},
+		{http.MethodGet, "/vehicles/" + testVIN + "/lock", http.StatusMethodNotAllowed, http.MethodPost},
+		{http.MethodGet, "/vehicles/" + testVIN + "/charge/start", http.StatusMethodNotAllowed, http.MethodPost},
+		{http.MethodGet, "/", http.StatusNotFound, ""},
+		{http.MethodGet, "/vehicles/" + testVIN, http.StatusNotFound, ""},
+		{http.MethodPost, "/vehicles/" + testVIN + "/climate", http.StatusNotFound, ""},
+		{http.MethodGet, "/vehicles/UNKNOWN/status", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		w := th.do(tt.method, tt.path)
+		if w.Code != tt.want {
+			t.Errorf("%s %s: got status %d, want %d: %s", tt.method, tt.path, w.Code, tt.want, w.Body)
+		}
+		if got := w.Header().Get("Allow"); got != tt.allow {
+			t.Errorf("%s %s: got Allow %q, want %q", tt.method, tt.path, got, tt.allow)
+		}
+	}
+}
+
+func TestHandlerStatusCached(t *testing.T) {
+	th := newTestHandler(t)
+
+	w := th.do(http.MethodGet, "/vehicles/"+testVIN+"/status")
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d: %s", w.Code, w.Body)
+	}
+	if got, want := w.Header().Get("Age"), "0"; got != want {
+		t.Errorf("got Age %q, want %q", got, want)
+	}
+	if got, want := w.Header().Get("Cache-Control"), "private, max-age=300"; got != want {
+		t.Errorf("got Cache-Control %q, want %q", got, want)
+	}
+
+	calls := th.apiCalls()
+	th.clock.advance(2 * time.Minute)
+	w = th.do(http.MethodGet, "/vehicles/"+testVIN+"/status")
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d: %s", w.Code, w.Body)
+	}
+	if got := th.apiCalls(); got != calls {
+		t.Errorf("cache hit made %d api calls", got-calls)
+	}
+	if got, want := w.Header().Get("Age"), "120"; got != want {
+		t.Errorf("got Age %q, want %q", got, want)
+	}
+	if got, want := w.Header().Get("Cache-Control"), "private, max-age=180"; got != want {
+		t.Errorf("got Cache-Control %q, want %q", got, want)
+	}
+
+	th.clock.advance(DefaultStatusTTL)
+	th.do(http.MethodGet, "/vehicles/"+testVIN+"/status")
+	if th.apiCalls() == calls {
+		t.Error("expired status was not fetched again")
+	}
+}
+
+func TestHandlerCommandInvalidatesStatus(t *testing.T) {
+	th := newTestHandler(t)
+
+	status := func() map[string]interface{} {
+		t.Helper()
+		w := th.do(http.MethodGet, "/vehicles/"+testVIN+"/status")
+		if w.Code != http.StatusOK {
+			t.Fatalf("got status %d: %s", w.Code, w.Body)
+		}
+		var s map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &s); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	before := status()
+	w := th.do(http.MethodPost, "/vehicles/"+testVIN+"/unlock")
+	if w.Code != http.StatusOK {
+		t.Fatalf("unlock: got status %d: %s", w.Code, w.Body)
+	}
+	var res CommandResult
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil || res != (CommandResult{VIN: testVIN, Command: "unlock"}) {
+		t.Errorf("got result %+v, %v", res, err)
+	}
+
+	calls := th.apiCalls()
+	after := status()
+	if th.apiCalls() == calls {
+		t.Error("status after a command was served from the cache")
+	}
+	if before["doorIsLocked"] == after["doorIsLocked"] {
+		t.Errorf("status still reports doorIsLocked %v", after["doorIsLocked"])
+	}
+}
+
+func TestHandlerCommandCancelled(t *testing.T) {
+	th := newTestHandler(t)
+	// records never resolve, the command waits until it times out
+	th.srv.SetCommandResult("pending")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := httptest.NewRequest(http.MethodPost, "/vehicles/"+testVIN+"/lock", nil).WithContext(ctx)
+	r.Header.Set("X-API-Key", testKey)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		th.ServeHTTP(httptest.NewRecorder(), r)
+	}()
+
+	select {
+	case <-th.records:
+	case <-time.After(5 * time.Second):
+		t.Fatal("command was not sent")
+	}
+	// let the poll complete so the cancel hits the wait before the next one,
+	// which is well above the time allowed to return
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("handler kept waiting for the command after the client was gone")
+	}
+}
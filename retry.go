@@ -0,0 +1,157 @@
+package goblue
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy configures how failed api calls are repeated.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts per call, including the
+	// first one.
+	MaxAttempts int
+	// BaseDelay is the backoff before the first retry, it doubles with every
+	// further attempt up to MaxDelay. A random jitter is applied to each
+	// delay. A Retry-After header of the response takes precedence, calls
+	// asked to wait longer than MaxDelay are not repeated.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Retryable decides whether a failed attempt is repeated. It defaults to
+	// network errors and server side failures.
+	Retryable func(*http.Response, error) bool
+	// RetryCommands allows to repeat remote commands, which might execute
+	// them more than once.
+	RetryCommands bool
+}
+
+// DefaultRetryPolicy returns a policy making up to three attempts.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    10 * time.Second,
+		Retryable:   IsRetryable,
+	}
+}
+
+// IsRetryable reports whether a request failed with a network error or a 5xx
+// status code.
+func IsRetryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, ErrRateLimited) &&
+			!errors.Is(err, context.Canceled) &&
+			!errors.Is(err, context.DeadlineExceeded)
+	}
+	return resp.StatusCode >= http.StatusInternalServerError
+}
+
+// WithRetry repeats failed api calls according to the given policy.
+func WithRetry(p RetryPolicy) ClientOptions {
+	return func(c *Client) error {
+		if p.Retryable == nil {
+			p.Retryable = IsRetryable
+		}
+		c.retry = &p
+		return nil
+	}
+}
+
+type commandKey struct{}
+
+// markCommand flags the request as a remote command.
+func markCommand(req *http.Request) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), commandKey{}, true))
+}
+
+func isCommand(req *http.Request) bool {
+	command, _ := req.Context().Value(commandKey{}).(bool)
+	return command
+}
+
+// backoff returns the randomized delay before the given retry.
+func (p *RetryPolicy) backoff(retry int) time.Duration {
+	d := p.BaseDelay << uint(retry)
+	if d <= 0 || (p.MaxDelay > 0 && d > p.MaxDelay) {
+		d = p.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// retryTransport repeats failed round trips.
+type retryTransport struct {
+	policy    *RetryPolicy
+	transport http.RoundTripper
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	attempts := t.policy.MaxAttempts
+	if isCommand(req) && !t.policy.RetryCommands {
+		attempts = 1
+	}
+	if req.Body != nil && req.GetBody == nil {
+		attempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := t.transport.RoundTrip(req)
+		if attempt >= attempts || !t.policy.Retryable(resp, err) {
+			return resp, err
+		}
+		delay := t.policy.backoff(attempt - 1)
+		if after, ok := retryAfter(resp); ok {
+			if t.policy.MaxDelay > 0 && after > t.policy.MaxDelay {
+				return resp, err
+			}
+			delay = after
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// retryAfter returns the delay requested by the Retry-After header of a
+// response, given in seconds or as a date.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
@@ -0,0 +1,156 @@
+package goblue_test
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/frzifus/goblue"
+	"github.com/frzifus/goblue/bluelinktest"
+)
+
+// countingTransport counts the round trips whose path contains a substring.
+type countingTransport struct {
+	transport http.RoundTripper
+
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	for _, part := range []string{"/status", "/control/"} {
+		if strings.Contains(req.URL.Path, part) {
+			t.counts[part]++
+		}
+	}
+	t.mu.Unlock()
+	return t.transport.RoundTrip(req)
+}
+
+func (t *countingTransport) count(part string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.counts[part]
+}
+
+// newRetryVehicle returns the vehicle of a client retrying by the policy and
+// the transport counting its attempts.
+func newRetryVehicle(t *testing.T, p goblue.RetryPolicy) (*bluelinktest.Server, *goblue.Vehicle, *countingTransport) {
+	t.Helper()
+	srv := bluelinktest.NewServer(bluelinktest.Credentials{Username: "user", Password: "secret", PIN: "1234"})
+	t.Cleanup(srv.Close)
+	srv.AddVehicle(bluelinktest.Vehicle{ID: "00000000-0000-0000-0000-0000000000d1", VIN: "VIN-1", Type: "EV"})
+
+	rt := &countingTransport{transport: srv.Client().Transport, counts: map[string]int{}}
+	opts := append(srv.ClientOptions(), goblue.WithTransport(rt), goblue.WithRetry(p))
+	c, err := goblue.NewClient(srv.Config(goblue.BrandKia), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Authenticate(); err != nil {
+		t.Fatal(err)
+	}
+	vs, err := c.Vehicles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv, vs[0], rt
+}
+
+func TestRetryBacksOffExponentially(t *testing.T) {
+	srv, v, rt := newRetryVehicle(t, goblue.RetryPolicy{MaxAttempts: 3, BaseDelay: 40 * time.Millisecond, MaxDelay: time.Second})
+	srv.Fail(bluelinktest.EndpointStatus, bluelinktest.FailureUnavailable, 2)
+
+	start := time.Now()
+	if _, err := v.Status(); err != nil {
+		t.Fatal(err)
+	}
+	if got := rt.count("/status"); got != 3 {
+		t.Errorf("got %d attempts, want 3", got)
+	}
+	// the jitter keeps at least half of the 40ms and 80ms delays
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("retried after %v, want a backoff of at least 60ms", elapsed)
+	}
+
+	srv.Fail(bluelinktest.EndpointStatus, bluelinktest.FailureUnavailable, 3)
+	if _, err := v.Status(); err == nil {
+		t.Error("status succeeded although all attempts failed")
+	}
+	if got := rt.count("/status"); got != 6 {
+		t.Errorf("got %d attempts, want 3 more", got)
+	}
+}
+
+func TestRetryHonoursRetryAfter(t *testing.T) {
+	unavailable := bluelinktest.FailureUnavailable
+	unavailable.RetryAfter = 1
+
+	srv, v, rt := newRetryVehicle(t, goblue.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Second})
+	srv.Fail(bluelinktest.EndpointStatus, unavailable, 1)
+	start := time.Now()
+	if _, err := v.Status(); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %v, want the 1s of Retry-After", elapsed)
+	}
+	if got := rt.count("/status"); got != 2 {
+		t.Errorf("got %d attempts, want 2", got)
+	}
+
+	// a server asking to wait longer than MaxDelay is not retried
+	srv, v, rt = newRetryVehicle(t, goblue.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: 500 * time.Millisecond})
+	srv.Fail(bluelinktest.EndpointStatus, unavailable, 1)
+	if _, err := v.Status(); err == nil {
+		t.Error("status succeeded without a retry")
+	}
+	if got := rt.count("/status"); got != 1 {
+		t.Errorf("got %d attempts, want 1", got)
+	}
+}
+
+func TestRetrySkipsCommands(t *testing.T) {
+	policy := goblue.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+
+	srv, v, rt := newRetryVehicle(t, policy)
+	srv.Fail(bluelinktest.EndpointControl, bluelinktest.FailureUnavailable, 1)
+	if err := v.Unlock(); err == nil {
+		t.Fatal("failed command succeeded")
+	}
+	if got := rt.count("/control/"); got != 1 {
+		t.Errorf("command sent %d times, want once", got)
+	}
+	if cmds := srv.Commands(); len(cmds) != 0 {
+		t.Errorf("failed command reached the vehicle: %+v", cmds)
+	}
+
+	// commands are retried only if asked for, the body is sent again
+	policy.RetryCommands = true
+	srv, v, rt = newRetryVehicle(t, policy)
+	srv.Fail(bluelinktest.EndpointControl, bluelinktest.FailureUnavailable, 1)
+	if err := v.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+	if got := rt.count("/control/"); got != 2 {
+		t.Errorf("command sent %d times, want twice", got)
+	}
+	if cmds := srv.Commands(); len(cmds) != 1 || cmds[0].Payload["action"] != "open" {
+		t.Errorf("got commands %+v, want one unlock", cmds)
+	}
+}
+
+func TestRetryStopsOnRateLimit(t *testing.T) {
+	srv, v, rt := newRetryVehicle(t, goblue.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})
+	srv.Fail(bluelinktest.EndpointStatus, bluelinktest.FailureRateLimited, 1)
+	if _, err := v.Status(); !errors.Is(err, goblue.ErrRateLimited) {
+		t.Errorf("got %v, want ErrRateLimited", err)
+	}
+	if got := rt.count("/status"); got != 1 {
+		t.Errorf("got %d attempts, want 1", got)
+	}
+}
@@ -0,0 +1,67 @@
+package goblue_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/frzifus/goblue"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of the status corpus")
+
+const goldenSuffix = ".golden.json"
+
+func TestDecodeStatusCorpus(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "status", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var samples int
+	for _, path := range paths {
+		if strings.HasSuffix(path, goldenSuffix) {
+			continue
+		}
+		samples++
+
+		path := path
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			body, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			status, err := goblue.DecodeStatus(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := json.MarshalIndent(status, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, '\n')
+
+			golden := strings.TrimSuffix(path, ".json") + goldenSuffix
+			if *update {
+				if err := ioutil.WriteFile(golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("decoded status differs from %s\ngot:\n%s\nwant:\n%s", golden, got, want)
+			}
+		})
+	}
+	if samples == 0 {
+		t.Fatal("no samples in testdata/status")
+	}
+}
@@ -0,0 +1,106 @@
+package goblue
+
+import (
+	"context"
+	"net/http"
+)
+
+// Attribute is a key value pair attached to a span.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// Tracer starts spans. It follows the OpenTelemetry tracing api, so adapting
+// an OpenTelemetry tracer only requires converting the attributes.
+type Tracer interface {
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+}
+
+// Span is a single traced operation.
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+// WithTracer traces every logical operation like Authenticate or
+// Vehicle.Status and each http call made by it. The Context variants of the
+// operations, like Vehicle.StatusContext, start their spans as children of
+// the span carried by the passed context.
+func WithTracer(t Tracer) ClientOptions {
+	return func(c *Client) error {
+		c.tracer = &attributedTracer{
+			tracer: t,
+			attrs: []Attribute{
+				{Key: "goblue.brand", Value: string(c.cfg.Brand)},
+				{Key: "goblue.region", Value: string(c.cfg.Region)},
+			},
+		}
+		c.middleware = append(c.middleware, tracingMiddleware(c.tracer))
+		return nil
+	}
+}
+
+// attributedTracer adds attributes to every span it starts.
+type attributedTracer struct {
+	tracer Tracer
+	attrs  []Attribute
+}
+
+func (t *attributedTracer) Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	return t.tracer.Start(ctx, name, append(append([]Attribute{}, t.attrs...), attrs...)...)
+}
+
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, _ string, _ ...Attribute) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(...Attribute) {}
+func (noopSpan) RecordError(error)          {}
+func (noopSpan) End()                       {}
+
+// operation is the span of a logical operation.
+type operation struct {
+	Span
+}
+
+// startOperation starts the span of an operation as child of the span
+// carried by ctx, if any.
+func startOperation(ctx context.Context, t Tracer, name string, attrs ...Attribute) (context.Context, operation) {
+	ctx, span := t.Start(ctx, name, attrs...)
+	return ctx, operation{span}
+}
+
+// end records the error the operation failed with and ends its span.
+func (o operation) end(err *error) {
+	if *err != nil {
+		o.RecordError(*err)
+	}
+	o.End()
+}
+
+func tracingMiddleware(t Tracer) Middleware {
+	return func(next HttpClient) HttpClient {
+		return HttpClientFunc(func(req *http.Request) (*http.Response, error) {
+			ctx, span := t.Start(req.Context(), "HTTP "+req.Method,
+				Attribute{Key: "http.method", Value: req.Method},
+				Attribute{Key: "http.url", Value: redactURL(req.URL)},
+				Attribute{Key: "goblue.endpoint", Value: endpointName(req)},
+			)
+			defer span.End()
+
+			resp, err := next.Do(req.WithContext(ctx))
+			if err != nil {
+				span.RecordError(err)
+				return resp, err
+			}
+			span.SetAttributes(Attribute{Key: "http.status_code", Value: resp.StatusCode})
+			return resp, err
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package goblue
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+type spanKey struct{}
+
+type testSpan struct {
+	name   string
+	parent *testSpan
+}
+
+func (*testSpan) SetAttributes(...Attribute) {}
+func (*testSpan) RecordError(error)          {}
+func (*testSpan) End()                       {}
+
+type testTracer struct {
+	mu    sync.Mutex
+	spans []*testSpan
+}
+
+func (t *testTracer) Start(ctx context.Context, name string, _ ...Attribute) (context.Context, Span) {
+	parent, _ := ctx.Value(spanKey{}).(*testSpan)
+	s := &testSpan{name: name, parent: parent}
+	t.mu.Lock()
+	t.spans = append(t.spans, s)
+	t.mu.Unlock()
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+func TestOperationJoinsCallerTrace(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"retCode": "S", "resMsg": {"vehicles": [{"vehicleId": "a", "vin": "VIN1", "type": "EV"}]}}`))
+	}))
+	defer srv.Close()
+
+	tracer := &testTracer{}
+	c, err := NewClient(Config{Brand: BrandHyundai, Region: RegionEU}, WithBaseURL(srv.URL), WithTracer(tracer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.auth.setSession(session{AccessToken: "Bearer token"})
+
+	ctx, root := tracer.Start(context.Background(), "caller")
+	if _, err := c.VehiclesContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tracer.spans) != 3 {
+		t.Fatalf("got %d spans, want caller, operation and http call", len(tracer.spans))
+	}
+	op, call := tracer.spans[1], tracer.spans[2]
+	if op.name != "goblue.Client.Vehicles" || op.parent != root {
+		t.Errorf("operation span %q has parent %v, want caller span", op.name, op.parent)
+	}
+	if call.parent != op {
+		t.Errorf("http span %q is not a child of the operation", call.name)
+	}
+}
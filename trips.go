@@ -0,0 +1,230 @@
+package goblue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	tripPeriodMonth = 0
+	tripPeriodDay   = 1
+
+	tripMonthLayout = "200601"
+	tripDayLayout   = "20060102"
+	tripTimeLayout  = "20060102150405"
+)
+
+// Trip describes a single drive as recorded by the vehicle.
+type Trip struct {
+	StartedAt time.Time
+	DriveTime time.Duration
+	IdleTime  time.Duration
+	Distance  int     // km
+	AvgSpeed  float64 // km/h
+	MaxSpeed  float64 // km/h
+	// Energy is the energy used in Wh. The api reports the consumption per
+	// day only, it is split between the trips of a day by their distance.
+	// Zero for vehicles without an electric drivetrain and trips older than
+	// the 30 days covered by DriveHistory.
+	Energy int
+}
+
+// TripDay holds the number of trips driven on a single day.
+type TripDay struct {
+	Date  time.Time
+	Count int
+}
+
+// TripSummary aggregates all trips of a month.
+type TripSummary struct {
+	Month     time.Time
+	Days      []TripDay
+	DriveTime time.Duration
+	IdleTime  time.Duration
+	Distance  int     // km
+	AvgSpeed  float64 // km/h
+	MaxSpeed  float64 // km/h
+}
+
+// TripSummary returns the trip statistics of the month containing the given
+// time. Months and days are those of the api, reported in Europe/Berlin.
+func (v *Vehicle) TripSummary(month time.Time) (*TripSummary, error) {
+	return v.TripSummaryContext(context.Background(), month)
+}
+
+// TripSummaryContext is like TripSummary, using ctx for the requests and the
+// parent span.
+func (v *Vehicle) TripSummaryContext(ctx context.Context, month time.Time) (_ *TripSummary, err error) {
+	ctx, op := v.startOperation(ctx, "goblue.Vehicle.TripSummary")
+	defer op.end(&err)
+
+	return v.tripSummary(ctx, month)
+}
+
+func (v *Vehicle) tripSummary(ctx context.Context, month time.Time) (*TripSummary, error) {
+	month = month.In(apiLocation)
+	payload := map[string]interface{}{
+		"tripPeriodType": tripPeriodMonth,
+		"setTripMonth":   month.Format(tripMonthLayout),
+	}
+
+	msg := struct {
+		Tripdaylist []struct {
+			Tripdayinmonth string `json:"tripDayInMonth"`
+			Tripcntday     int    `json:"tripCntDay"`
+		} `json:"tripDayList"`
+		Tripdrvtime  int `json:"tripDrvTime"`
+		Tripidletime int `json:"tripIdleTime"`
+		Tripdist     int `json:"tripDist"`
+		Tripavgspeed struct {
+			Value float64 `json:"value"`
+		} `json:"tripAvgSpeed"`
+		Tripmaxspeed struct {
+			Value float64 `json:"value"`
+		} `json:"tripMaxSpeed"`
+	}{}
+
+	uri := fmt.Sprintf(v.auth.URI+v.endpoints.TripInfo, v.id)
+	if err := v.doSpaRequest(ctx, http.MethodPost, uri, payload, &msg); err != nil {
+		return nil, err
+	}
+
+	summary := &TripSummary{
+		Month:     time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, apiLocation),
+		DriveTime: time.Duration(msg.Tripdrvtime) * time.Minute,
+		IdleTime:  time.Duration(msg.Tripidletime) * time.Minute,
+		Distance:  msg.Tripdist,
+		AvgSpeed:  msg.Tripavgspeed.Value,
+		MaxSpeed:  msg.Tripmaxspeed.Value,
+	}
+	for _, d := range msg.Tripdaylist {
+		date, err := time.ParseInLocation(tripDayLayout, d.Tripdayinmonth, apiLocation)
+		if err != nil {
+			return nil, err
+		}
+		summary.Days = append(summary.Days, TripDay{Date: date, Count: d.Tripcntday})
+	}
+
+	return summary, nil
+}
+
+// Trips returns all trips started between from and to. Only days reported
+// with trips by the monthly summary are queried in detail. The energy of
+// electric vehicles is taken from the drive history.
+func (v *Vehicle) Trips(from, to time.Time) ([]*Trip, error) {
+	return v.TripsContext(context.Background(), from, to)
+}
+
+// TripsContext is like Trips, using ctx for the requests and the parent span.
+func (v *Vehicle) TripsContext(ctx context.Context, from, to time.Time) (_ []*Trip, err error) {
+	ctx, op := v.startOperation(ctx, "goblue.Vehicle.Trips")
+	defer op.end(&err)
+
+	var trips []*Trip
+	start := from.In(apiLocation)
+	month := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, apiLocation)
+	for ; !month.After(to); month = month.AddDate(0, 1, 0) {
+		summary, err := v.tripSummary(ctx, month)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range summary.Days {
+			if d.Count == 0 || d.Date.AddDate(0, 0, 1).Before(from) || d.Date.After(to) {
+				continue
+			}
+			daily, err := v.tripsOfDay(ctx, d.Date)
+			if err != nil {
+				return nil, err
+			}
+			for _, t := range daily {
+				if t.StartedAt.Before(from) || t.StartedAt.After(to) {
+					continue
+				}
+				trips = append(trips, t)
+			}
+		}
+	}
+
+	if caps := v.Capabilities(); len(trips) == 0 || !caps.Has(CapabilityEV) && !caps.Has(CapabilityPHEV) {
+		return trips, nil
+	}
+	history, err := v.driveHistory(ctx)
+	if errors.Is(err, ErrUnsupported) {
+		return trips, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	assignEnergy(trips, history.Days)
+	return trips, nil
+}
+
+// assignEnergy splits the consumption of each day between the trips driven
+// on it, weighted by their distance.
+func assignEnergy(trips []*Trip, days []DailyConsumption) {
+	byDay := map[string][]*Trip{}
+	distance := map[string]int{}
+	for _, t := range trips {
+		day := t.StartedAt.Format(tripDayLayout)
+		byDay[day] = append(byDay[day], t)
+		distance[day] += t.Distance
+	}
+
+	for _, d := range days {
+		day := d.Date.Format(tripDayLayout)
+		if distance[day] == 0 {
+			continue
+		}
+		for _, t := range byDay[day] {
+			t.Energy = d.Total * t.Distance / distance[day]
+		}
+	}
+}
+
+func (v *Vehicle) tripsOfDay(ctx context.Context, day time.Time) ([]*Trip, error) {
+	payload := map[string]interface{}{
+		"tripPeriodType": tripPeriodDay,
+		"setTripDay":     day.In(apiLocation).Format(tripDayLayout),
+	}
+
+	msg := struct {
+		Daytriplist []struct {
+			Tripday  string `json:"tripDay"`
+			Triplist []struct {
+				Triptime     string  `json:"tripTime"`
+				Tripdrvtime  int     `json:"tripDrvTime"`
+				Tripidletime int     `json:"tripIdleTime"`
+				Tripdist     int     `json:"tripDist"`
+				Tripavgspeed float64 `json:"tripAvgSpeed"`
+				Tripmaxspeed float64 `json:"tripMaxSpeed"`
+			} `json:"tripList"`
+		} `json:"dayTripList"`
+	}{}
+
+	uri := fmt.Sprintf(v.auth.URI+v.endpoints.TripInfo, v.id)
+	if err := v.doSpaRequest(ctx, http.MethodPost, uri, payload, &msg); err != nil {
+		return nil, err
+	}
+
+	var trips []*Trip
+	for _, d := range msg.Daytriplist {
+		for _, t := range d.Triplist {
+			started, err := time.ParseInLocation(tripTimeLayout, d.Tripday+t.Triptime, apiLocation)
+			if err != nil {
+				return nil, err
+			}
+			trips = append(trips, &Trip{
+				StartedAt: started,
+				DriveTime: time.Duration(t.Tripdrvtime) * time.Minute,
+				IdleTime:  time.Duration(t.Tripidletime) * time.Minute,
+				Distance:  t.Tripdist,
+				AvgSpeed:  t.Tripavgspeed,
+				MaxSpeed:  t.Tripmaxspeed,
+			})
+		}
+	}
+	return trips, nil
+}
@@ -0,0 +1,139 @@
+package goblue
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAssignEnergy(t *testing.T) {
+	day := func(d, h int) time.Time { return time.Date(2024, 3, d, h, 0, 0, 0, time.UTC) }
+	trips := []*Trip{
+		{StartedAt: day(1, 8), Distance: 10},
+		{StartedAt: day(1, 17), Distance: 30},
+		{StartedAt: day(2, 9), Distance: 5},
+		{StartedAt: day(3, 9), Distance: 0},
+	}
+	days := []DailyConsumption{
+		{Date: day(1, 0), EnergyConsumption: EnergyConsumption{Total: 8000}},
+		{Date: day(3, 0), EnergyConsumption: EnergyConsumption{Total: 500}},
+	}
+
+	assignEnergy(trips, days)
+
+	for i, want := range []int{2000, 6000, 0, 0} {
+		if got := trips[i].Energy; got != want {
+			t.Errorf("trip %d: energy %d, want %d", i, got, want)
+		}
+	}
+}
+
+// newFixtureVehicle returns an authenticated vehicle of the given type whose
+// requests are answered by handler.
+func newFixtureVehicle(t *testing.T, typ string, handler http.HandlerFunc) *Vehicle {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	c, err := NewClient(Config{Brand: BrandKia, Region: RegionEU}, WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.auth.setSession(session{AccessToken: "Bearer token"})
+	return NewVehicle("v1", "VIN1", "car", typ, BrandKia,
+		WithVehicleClient(c.api), WithVehicleAuth(c.auth), WithVehicleEndpoints(c.endpoints))
+}
+
+func TestTrips(t *testing.T) {
+	var payloads []map[string]interface{}
+	v := newFixtureVehicle(t, "GN", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/spa/vehicles/v1/tripinfo" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		var payload map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Error(err)
+		}
+		payloads = append(payloads, payload)
+
+		switch payload["tripPeriodType"] {
+		case float64(tripPeriodMonth):
+			w.Write([]byte(`{"retCode": "S", "resCode": "0000", "resMsg": {
+				"tripDayList": [
+					{"tripDayInMonth": "20240301", "tripCntDay": 2},
+					{"tripDayInMonth": "20240302", "tripCntDay": 0}
+				],
+				"tripDrvTime": 95, "tripIdleTime": 12, "tripDist": 71,
+				"tripAvgSpeed": {"value": 44.8}, "tripMaxSpeed": {"value": 131}
+			}, "msgId": "m1"}`))
+		default:
+			w.Write([]byte(`{"retCode": "S", "resCode": "0000", "resMsg": {
+				"dayTripList": [{"tripDay": "20240301", "tripList": [
+					{"tripTime": "003015", "tripDrvTime": 40, "tripIdleTime": 5, "tripDist": 31, "tripAvgSpeed": 46.5, "tripMaxSpeed": 131},
+					{"tripTime": "171000", "tripDrvTime": 55, "tripIdleTime": 7, "tripDist": 40, "tripAvgSpeed": 43.6, "tripMaxSpeed": 98}
+				]}]
+			}, "msgId": "m2"}`))
+		}
+	})
+
+	summary, err := v.TripSummary(time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantSummary := &TripSummary{
+		Month: time.Date(2024, 3, 1, 0, 0, 0, 0, apiLocation),
+		Days: []TripDay{
+			{Date: time.Date(2024, 3, 1, 0, 0, 0, 0, apiLocation), Count: 2},
+			{Date: time.Date(2024, 3, 2, 0, 0, 0, 0, apiLocation), Count: 0},
+		},
+		DriveTime: 95 * time.Minute,
+		IdleTime:  12 * time.Minute,
+		Distance:  71,
+		AvgSpeed:  44.8,
+		MaxSpeed:  131,
+	}
+	if !reflect.DeepEqual(summary, wantSummary) {
+		t.Errorf("got summary %+v, want %+v", summary, wantSummary)
+	}
+
+	// the api reports Berlin wall clock times, 00:30 on March 1st is still
+	// February in UTC
+	trips, err := v.Trips(time.Date(2024, 2, 29, 23, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 23, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*Trip{
+		{StartedAt: time.Date(2024, 2, 29, 23, 30, 15, 0, time.UTC), DriveTime: 40 * time.Minute, IdleTime: 5 * time.Minute,
+			Distance: 31, AvgSpeed: 46.5, MaxSpeed: 131},
+		{StartedAt: time.Date(2024, 3, 1, 16, 10, 0, 0, time.UTC), DriveTime: 55 * time.Minute, IdleTime: 7 * time.Minute,
+			Distance: 40, AvgSpeed: 43.6, MaxSpeed: 98},
+	}
+	if len(trips) != len(want) {
+		t.Fatalf("got %d trips, want %d", len(trips), len(want))
+	}
+	for i := range want {
+		got := *trips[i]
+		if !got.StartedAt.Equal(want[i].StartedAt) {
+			t.Errorf("trip %d started at %v, want %v", i, got.StartedAt, want[i].StartedAt)
+		}
+		got.StartedAt = want[i].StartedAt
+		if got != *want[i] {
+			t.Errorf("trip %d: got %+v, want %+v", i, got, *want[i])
+		}
+	}
+
+	for i, want := range []map[string]interface{}{
+		{"tripPeriodType": float64(tripPeriodMonth), "setTripMonth": "202403"},
+		{"tripPeriodType": float64(tripPeriodMonth), "setTripMonth": "202403"},
+		{"tripPeriodType": float64(tripPeriodDay), "setTripDay": "20240301"},
+	} {
+		if i >= len(payloads) || !reflect.DeepEqual(payloads[i], want) {
+			t.Errorf("request %d: got payloads %v, want %v", i, payloads, want)
+		}
+	}
+}
@@ -1,13 +1,14 @@
 package goblue
 
 import (
+	"context"
 	"io"
 	"net/http"
 )
 
 // newHttpRequest builds and executes HTTP request and returns the response
-func newHttpRequest(method, uri string, data io.Reader, headers ...map[string]string) (*http.Request, error) {
-	req, err := http.NewRequest(method, uri, data)
+func newHttpRequest(ctx context.Context, method, uri string, data io.Reader, headers ...map[string]string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, uri, data)
 	if err == nil {
 		for _, headers := range headers {
 			for k, v := range headers {
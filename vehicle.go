@@ -1,13 +1,18 @@
 package goblue
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 )
 
+// statusTimeLayout is the layout of the times reported by the status and
+// location endpoints, in the zone of apiLocation.
+const statusTimeLayout = "20060102150405"
+
 type HttpClient interface {
 	Do(req *http.Request) (*http.Response, error)
 }
@@ -21,6 +26,21 @@ type VehicleStatus struct {
 	targetSocAC  int
 	targetSocDC  int
 	plugState    int // TODO: 0 == unplugged
+	windows      Windows
+}
+
+// Windows holds the open state of each window and the sunroof.
+type Windows struct {
+	FrontLeft  bool `json:"frontLeft"`
+	FrontRight bool `json:"frontRight"`
+	BackLeft   bool `json:"backLeft"`
+	BackRight  bool `json:"backRight"`
+	Sunroof    bool `json:"sunroof"`
+}
+
+// Open reports whether any window or the sunroof is open.
+func (w Windows) Open() bool {
+	return w.FrontLeft || w.FrontRight || w.BackLeft || w.BackRight || w.Sunroof
 }
 
 func (v *VehicleStatus) UpdatedAt() time.Time {
@@ -35,6 +55,11 @@ func (v *VehicleStatus) IsCharging() bool {
 	return v.isCharging
 }
 
+// PluggedIn reports whether a charging cable is connected.
+func (v *VehicleStatus) PluggedIn() bool {
+	return v.plugState != 0
+}
+
 func (v *VehicleStatus) SoC() int {
 	return v.batterySoc
 }
@@ -58,16 +83,64 @@ func (v *VehicleStatus) TargetSocAC() int {
 	return v.targetSocAC
 }
 
-type StartOptions struct{} // ClimateOptions || StartOptions
+func (v *VehicleStatus) Windows() Windows {
+	return v.windows
+}
+
+func (v *VehicleStatus) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		UpdatedAt    time.Time `json:"updatedAt"`
+		DoorIsLocked bool      `json:"doorIsLocked"`
+		IsCharging   bool      `json:"isCharging"`
+		PlugState    int       `json:"plugState"`
+		SoC          int       `json:"soc"`
+		RangeLeft    int       `json:"rangeLeft"`
+		TargetSocAC  int       `json:"targetSocAC"`
+		TargetSocDC  int       `json:"targetSocDC"`
+		Windows      Windows   `json:"windows"`
+	}{
+		UpdatedAt:    v.updatedAt,
+		DoorIsLocked: v.doorIsLocked,
+		IsCharging:   v.isCharging,
+		PlugState:    v.plugState,
+		SoC:          v.batterySoc,
+		RangeLeft:    v.rangeLeft,
+		TargetSocAC:  v.targetSocAC,
+		TargetSocDC:  v.targetSocDC,
+		Windows:      v.windows,
+	})
+}
+
+// DefaultTemperature is the cabin temperature in celsius used by Start if no
+// temperature is given.
+const DefaultTemperature = 21.0
+
+const (
+	minTemperature = 14.0
+	maxTemperature = 30.0
+)
+
+// StartOptions configure the climate control started by Start.
+type StartOptions struct {
+	// Temperature is the target cabin temperature in celsius, in half degree
+	// steps between 14 and 30.
+	Temperature float64
+	// Defrost enables the front windshield defroster.
+	Defrost bool
+	// Heating enables the heating of the rear window and side mirrors.
+	Heating bool
+}
 
 type VehicleOption func(*Vehicle)
 
+// WithVehicleClient sets the request pipeline of the vehicle. It has to stamp
+// the requests, as the one of Client does.
 func WithVehicleClient(h HttpClient) VehicleOption {
 	return func(v *Vehicle) {
 		v.http = h
 	}
 }
-func WithVehicleAuth(a auth) VehicleOption {
+func WithVehicleAuth(a *auth) VehicleOption {
 	return func(v *Vehicle) {
 		v.auth = a
 	}
@@ -77,19 +150,49 @@ func WithVehicleEndpoints(e endpoints) VehicleOption {
 		v.endpoints = e
 	}
 }
+func WithVehicleCapabilities(c Capabilities) VehicleOption {
+	return func(v *Vehicle) {
+		v.capabilities = c
+	}
+}
+func WithVehicleTracer(t Tracer) VehicleOption {
+	return func(v *Vehicle) {
+		v.tracer = t
+	}
+}
+func WithVehicleInfo(i VehicleInfo) VehicleOption {
+	return func(v *Vehicle) {
+		v.info = i
+	}
+}
+
+// VehicleInfo contains the registration metadata of a vehicle.
+type VehicleInfo struct {
+	Nickname      string
+	Master        bool
+	CarShare      int
+	RegDate       time.Time
+	ModelCode     string
+	ModelName     string
+	BodyType      string
+	InteriorColor string
+	ExteriorColor string
+}
 
 func NewVehicle(
 	id, vin, name, vtype string,
 	b Brand,
 	opts ...VehicleOption,
 ) *Vehicle {
-	v := &Vehicle{id: id, vin: vin, name: name, vtype: vtype, brand: b}
+	v := &Vehicle{id: id, vin: vin, name: name, vtype: vtype, brand: b, tracer: noopTracer{}, auth: &auth{}}
 	for _, o := range opts {
 		o(v)
 	}
 	return v
 }
 
+// Vehicle is a vehicle of an account. It is safe for concurrent use by
+// multiple goroutines.
 type Vehicle struct {
 	id    string
 	vin   string
@@ -97,9 +200,19 @@ type Vehicle struct {
 	vtype string
 	brand Brand
 
+	mu           sync.RWMutex // guards capabilities
+	capabilities Capabilities
+	info         VehicleInfo
+
 	http      HttpClient
-	auth      auth
+	auth      *auth
 	endpoints endpoints
+	tracer    Tracer
+
+	tokenMu            sync.Mutex // guards the control token
+	controlToken       string
+	controlTokenIssuer string // access token the control token was issued for
+	controlTokenExpiry time.Time
 }
 
 func (v *Vehicle) VIN() string  { return v.vin }
@@ -108,60 +221,105 @@ func (v *Vehicle) Name() string { return v.name }
 func (v *Vehicle) Type() string { return v.vtype }
 func (v *Vehicle) Brand() Brand { return v.brand }
 
-func (v *Vehicle) Unlock() error               { return ErrNotImplemented }
-func (v *Vehicle) Lock() error                 { return ErrNotImplemented }
-func (v *Vehicle) Start(...StartOptions) error { return ErrNotImplemented }
-func (v *Vehicle) Stop() error                 { return ErrNotImplemented }
-func (v *Vehicle) Location() (string, error)   { return "", ErrNotImplemented }
-func (v *Vehicle) Odometer() (string, error)   { return "", ErrNotImplemented }
-func (v *Vehicle) Status() (*VehicleStatus, error) {
-	if v.auth.AccessToken == "" {
+func (v *Vehicle) Info() VehicleInfo { return v.info }
+
+// Capabilities returns the features supported by the vehicle. Seat climate
+// and steering wheel heating are added once a status reports them.
+func (v *Vehicle) Capabilities() Capabilities {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.capabilities
+}
+
+// addCapabilities extends the capabilities by features found in a response.
+func (v *Vehicle) addCapabilities(caps ...Capability) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for _, c := range caps {
+		v.capabilities |= Capabilities(c)
+	}
+}
+
+func (v *Vehicle) Status() (_ *VehicleStatus, err error) {
+	return v.StatusContext(context.Background())
+}
+
+// StatusContext is like Status, using ctx for the requests and the parent
+// span.
+func (v *Vehicle) StatusContext(ctx context.Context) (_ *VehicleStatus, err error) {
+	ctx, op := v.startOperation(ctx, "goblue.Vehicle.Status")
+	defer op.end(&err)
+
+	if v.auth.session().AccessToken == "" {
 		return nil, ErrNotAuthenticated
 	}
+	if v.Capabilities().Has(CapabilityCCS2) {
+		return v.ccs2Status(ctx)
+	}
 
-	stamp, err := GetStampFromList(v.brand)
-	if err != nil {
+	msg := vehicleStatusResponse{}
+	uri := fmt.Sprintf(v.auth.URI+v.endpoints.Status, v.id)
+	if err := v.doSpaRequest(ctx, http.MethodGet, uri, nil, &msg.Resmsg); err != nil {
 		return nil, err
 	}
 
-	headers := map[string]string{
-		"Authorization":       v.auth.AccessToken,
-		"ccsp-device-id":      v.auth.CCSPDeviceID,
-		"ccsp-application-id": v.auth.CCSPApplicationID,
-		"offset":              "1",
-		"User-Agent":          v.auth.UserAgent,
-		"Stamp":               stamp,
+	if msg.Resmsg.Seatheaterventstate != nil {
+		v.addCapabilities(CapabilityClimateSeats)
+	}
+	if msg.Resmsg.Steerwheelheat != nil {
+		v.addCapabilities(CapabilityHeatedSteeringWheel)
 	}
 
-	uri := fmt.Sprintf(v.auth.URI+v.endpoints.Status, v.id)
-	req, err := newHttpRequest(http.MethodGet, uri, nil, headers)
-	if err != nil {
+	return msg.status()
+}
+
+// DecodeStatus decodes the body of a status response, as sent for vehicles
+// speaking either the classic or the ccs2 protocol. Times are read in the
+// zone of the EU api.
+func DecodeStatus(body []byte) (*VehicleStatus, error) {
+	if err := checkResponse(http.StatusOK, body); err != nil {
 		return nil, err
 	}
 
-	resp, err := v.http.Do(req)
-	if err != nil {
+	probe := struct {
+		Resmsg struct {
+			State json.RawMessage `json:"state"`
+		} `json:"resMsg"`
+	}{}
+	if err := json.Unmarshal(body, &probe); err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode == http.StatusUnauthorized ||
-		resp.StatusCode == http.StatusForbidden {
-		return nil, ErrNotAuthenticated
+
+	if len(probe.Resmsg.State) > 0 {
+		msg := struct {
+			Resmsg ccs2StatusResponse `json:"resMsg"`
+		}{}
+		if err := json.Unmarshal(body, &msg); err != nil {
+			return nil, err
+		}
+		return msg.Resmsg.status()
 	}
 
 	msg := vehicleStatusResponse{}
-	var buf bytes.Buffer
-	buf.ReadFrom(resp.Body)
-	if err := json.Unmarshal(buf.Bytes(), &msg); err != nil {
+	if err := json.Unmarshal(body, &msg); err != nil {
 		return nil, err
 	}
-	if msg.Retcode != apiCodeOk {
-		return nil, ErrNotAuthenticated
+	return msg.status()
+}
+
+// status maps the response onto the VehicleStatus model.
+func (msg *vehicleStatusResponse) status() (*VehicleStatus, error) {
+	updatedAt := time.Now()
+	if msg.Resmsg.Time != "" {
+		var err error
+		if updatedAt, err = time.ParseInLocation(statusTimeLayout, msg.Resmsg.Time, apiLocation); err != nil {
+			return nil, err
+		}
 	}
 
-	var rangebyfuel int
+	rangeLeft := msg.Resmsg.Dte.Value
 	if len(msg.Resmsg.Evstatus.Drvdistance) > 0 {
-		rangebyfuel = msg.Resmsg.Evstatus.Drvdistance[0].Rangebyfuel.Evmoderange.Value
+		rangeLeft = msg.Resmsg.Evstatus.Drvdistance[0].Rangebyfuel.Evmoderange.Value
 	}
 
 	var acTarget, dcTarget int
@@ -175,17 +333,47 @@ func (v *Vehicle) Status() (*VehicleStatus, error) {
 	}
 
 	return &VehicleStatus{
-		updatedAt:    time.Now(), // TODO: grep from response
+		updatedAt:    updatedAt,
 		doorIsLocked: msg.Resmsg.Doorlock,
 		isCharging:   msg.Resmsg.Evstatus.Batterycharge,
 		batterySoc:   msg.Resmsg.Evstatus.Batterystatus,
 		plugState:    msg.Resmsg.Evstatus.Batteryplugin,
-		rangeLeft:    rangebyfuel,
+		rangeLeft:    rangeLeft,
 		targetSocAC:  acTarget,
 		targetSocDC:  dcTarget,
+		windows: Windows{
+			FrontLeft:  msg.Resmsg.Windowopen.Frontleft != 0,
+			FrontRight: msg.Resmsg.Windowopen.Frontright != 0,
+			BackLeft:   msg.Resmsg.Windowopen.Backleft != 0,
+			BackRight:  msg.Resmsg.Windowopen.Backright != 0,
+			Sunroof:    msg.Resmsg.Sunroofopen,
+		},
 	}, nil
 }
 
+// doSpaRequest sends an authenticated request to the vehicle api and decodes
+// the resMsg of the response into out. A nil payload sends no body, a nil out
+// discards the response message.
+func (v *Vehicle) doSpaRequest(ctx context.Context, method, uri string, payload, out interface{}) error {
+	sess := v.auth.session()
+	if sess.AccessToken == "" {
+		return ErrNotAuthenticated
+	}
+
+	req, err := newJSONRequest(ctx, method, uri, payload, v.auth.spaHeaders(sess, sess.AccessToken))
+	if err != nil {
+		return err
+	}
+
+	_, err = doJSON(v.http, req, out)
+	return err
+}
+
+// startOperation starts the span of a logical operation on the vehicle.
+func (v *Vehicle) startOperation(ctx context.Context, name string) (context.Context, operation) {
+	return startOperation(ctx, v.tracer, name, Attribute{Key: "goblue.vehicle_id", Value: v.id})
+}
+
 type PlugType int
 
 const (
@@ -207,14 +395,25 @@ type vehicleStatusResponse struct {
 			Backleft   int `json:"backLeft"`
 			Backright  int `json:"backRight"`
 		} `json:"doorOpen"`
-		Trunkopen bool `json:"trunkOpen"`
-		Airtemp   struct {
+		Windowopen struct {
+			Frontleft  int `json:"frontLeft"`
+			Frontright int `json:"frontRight"`
+			Backleft   int `json:"backLeft"`
+			Backright  int `json:"backRight"`
+		} `json:"windowOpen"`
+		Sunroofopen bool `json:"sunroofOpen"`
+		Trunkopen   bool `json:"trunkOpen"`
+		Airtemp     struct {
 			Value        string `json:"value"`
 			Unit         int    `json:"unit"`
 			Hvactemptype int    `json:"hvacTempType"`
 		} `json:"airTemp"`
-		Defrost  bool `json:"defrost"`
-		Acc      bool `json:"acc"`
+		Defrost bool `json:"defrost"`
+		Acc     bool `json:"acc"`
+		Dte     struct {
+			Value int `json:"value"`
+			Unit  int `json:"unit"`
+		} `json:"dte"`
 		Evstatus struct {
 			Batterycharge bool `json:"batteryCharge"`
 			Batterystatus int  `json:"batteryStatus"`
@@ -331,12 +530,18 @@ type vehicleStatusResponse struct {
 				} `json:"targetSOClist"`
 			} `json:"reservChargeInfos"`
 		} `json:"evStatus"`
-		Ign3               bool `json:"ign3"`
-		Hoodopen           bool `json:"hoodOpen"`
-		Transcond          bool `json:"transCond"`
-		Steerwheelheat     int  `json:"steerWheelHeat"`
-		Sidebackwindowheat int  `json:"sideBackWindowHeat"`
-		Tirepressurelamp   struct {
+		Ign3                bool `json:"ign3"`
+		Hoodopen            bool `json:"hoodOpen"`
+		Transcond           bool `json:"transCond"`
+		Steerwheelheat      *int `json:"steerWheelHeat"`
+		Sidebackwindowheat  int  `json:"sideBackWindowHeat"`
+		Seatheaterventstate *struct {
+			Flseatheatstate int `json:"flSeatHeatState"`
+			Frseatheatstate int `json:"frSeatHeatState"`
+			Rlseatheatstate int `json:"rlSeatHeatState"`
+			Rrseatheatstate int `json:"rrSeatHeatState"`
+		} `json:"seatHeaterVentState"`
+		Tirepressurelamp struct {
 			Tirepressurelampall int `json:"tirePressureLampAll"`
 			Tirepressurelampfl  int `json:"tirePressureLampFL"`
 			Tirepressurelampfr  int `json:"tirePressureLampFR"`
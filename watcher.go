@@ -0,0 +1,312 @@
+package goblue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultWatchInterval keeps a watched vehicle well below the daily
+	// request limit of the api.
+	DefaultWatchInterval = 15 * time.Minute
+
+	defaultEventBuffer = 16
+	maxWatchBackoff    = 6 * time.Hour
+)
+
+// Event is a change of a watched vehicle, one of ChargingStarted,
+// ChargingStopped, DoorUnlocked, DoorLocked, PluggedIn, Unplugged,
+// SoCCrossed, StatusFailed or, with WithStatusUpdates, StatusUpdated.
+type Event interface {
+	event() EventBase
+}
+
+// EventBase holds the vehicle and the statuses an event was derived from.
+type EventBase struct {
+	Vehicle  *Vehicle
+	Previous *VehicleStatus
+	Current  *VehicleStatus
+}
+
+func (e EventBase) event() EventBase { return e }
+
+// ChargingStarted is emitted once the vehicle started charging.
+type ChargingStarted struct{ EventBase }
+
+// ChargingStopped is emitted once the vehicle stopped charging.
+type ChargingStopped struct{ EventBase }
+
+// DoorUnlocked is emitted once the doors of the vehicle were unlocked.
+type DoorUnlocked struct{ EventBase }
+
+// DoorLocked is emitted once the doors of the vehicle were locked.
+type DoorLocked struct{ EventBase }
+
+// PluggedIn is emitted once a charging cable was connected.
+type PluggedIn struct{ EventBase }
+
+// Unplugged is emitted once the charging cable was removed.
+type Unplugged struct{ EventBase }
+
+// SoCCrossed is emitted once the state of charge reached a threshold
+// configured by WithSoCThresholds, or dropped below it.
+type SoCCrossed struct {
+	EventBase
+	Threshold int
+	Rising    bool
+}
+
+// StatusUpdated is emitted for every status read if the Watcher was created
+// with WithStatusUpdates, after the changes derived from it. Previous is nil
+// on the first poll.
+type StatusUpdated struct{ EventBase }
+
+// StatusFailed is emitted if the status of a vehicle could not be read.
+// Current and Previous hold the last known status.
+type StatusFailed struct {
+	EventBase
+	Err error
+}
+
+type WatcherOption func(*Watcher)
+
+// WithStatusUpdates emits StatusUpdated for every successful poll, for
+// consumers that need the whole status rather than its changes.
+func WithStatusUpdates() WatcherOption {
+	return func(w *Watcher) {
+		w.updates = true
+	}
+}
+
+// WithWatchInterval sets the time between two polls of a vehicle.
+func WithWatchInterval(d time.Duration) WatcherOption {
+	return func(w *Watcher) {
+		w.interval = d
+	}
+}
+
+// WithSoCThresholds emits SoCCrossed whenever the state of charge passes one
+// of the given percentages.
+func WithSoCThresholds(thresholds ...int) WatcherOption {
+	return func(w *Watcher) {
+		w.thresholds = append(w.thresholds, thresholds...)
+		sort.Ints(w.thresholds)
+	}
+}
+
+// WithWatchClient authenticates the client again and repeats the poll once
+// the status of a vehicle fails with ErrNotAuthenticated, so that a Watcher
+// outlives expired sessions. Polls the daily quota of the client cannot
+// cover are skipped until the next day. The vehicles must belong to the
+// client.
+func WithWatchClient(c *Client) WatcherOption {
+	return func(w *Watcher) {
+		w.client = c
+	}
+}
+
+// WithEventBuffer sets the capacity of the event channel. Polling pauses
+// while the channel is full.
+func WithEventBuffer(n int) WatcherOption {
+	return func(w *Watcher) {
+		w.buffer = n
+	}
+}
+
+// Watcher polls the status of vehicles and emits the changes between two
+// polls as events. Vehicles are polled one after another, once the api
+// reports ErrRateLimited the interval doubles until a poll succeeds again.
+type Watcher struct {
+	vehicles   []*Vehicle
+	interval   time.Duration
+	thresholds []int
+	buffer     int
+	updates    bool
+	client     *Client
+
+	events  chan Event
+	last    map[*Vehicle]*VehicleStatus
+	started int32 // set atomically by Run
+}
+
+// NewWatcher returns a Watcher for the given vehicles. It starts polling
+// once Run is called.
+func NewWatcher(vehicles []*Vehicle, opts ...WatcherOption) *Watcher {
+	w := &Watcher{
+		vehicles: vehicles,
+		interval: DefaultWatchInterval,
+		buffer:   defaultEventBuffer,
+		last:     map[*Vehicle]*VehicleStatus{},
+	}
+	for _, o := range opts {
+		o(w)
+	}
+	w.events = make(chan Event, w.buffer)
+	return w
+}
+
+// Events returns the channel events are delivered on. It is closed once Run
+// returns.
+func (w *Watcher) Events() <-chan Event {
+	return w.events
+}
+
+// Run polls the vehicles until the context is done and returns its error.
+// The first poll records the initial status of each vehicle without
+// emitting changes. Run may be called once, later calls fail with
+// ErrAlreadyRunning.
+func (w *Watcher) Run(ctx context.Context) error {
+	if !atomic.CompareAndSwapInt32(&w.started, 0, 1) {
+		return ErrAlreadyRunning
+	}
+	defer close(w.events)
+
+	backoff := w.interval
+	for {
+		var wait time.Duration
+		if next, err := w.quotaExhausted(); err != nil {
+			for _, v := range w.vehicles {
+				prev := w.last[v]
+				if err := w.emit(ctx, StatusFailed{EventBase{Vehicle: v, Previous: prev, Current: prev}, err}, nil); err != nil {
+					return err
+				}
+			}
+			if wait = time.Until(next); wait < w.interval {
+				wait = w.interval
+			}
+		} else {
+			limited := false
+			for _, v := range w.vehicles {
+				if err := w.poll(ctx, v); err != nil {
+					if errors.Is(err, ErrRateLimited) {
+						limited = true
+					}
+					if ctx.Err() != nil {
+						return ctx.Err()
+					}
+				}
+			}
+
+			if limited {
+				if backoff *= 2; backoff > maxWatchBackoff {
+					backoff = maxWatchBackoff
+				}
+			} else {
+				backoff = w.interval
+			}
+			wait = backoff
+		}
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// quotaExhausted returns ErrRateLimited and the start of the next day if the
+// daily quota of the client cannot cover polling all vehicles.
+func (w *Watcher) quotaExhausted() (time.Time, error) {
+	if w.client == nil {
+		return time.Time{}, nil
+	}
+	u := w.client.Quota()
+	if left := u.Remaining(); left < 0 || left >= len(w.vehicles) {
+		return time.Time{}, nil
+	}
+	return u.Day.AddDate(0, 0, 1), fmt.Errorf("%w: %d of %d daily calls left", ErrRateLimited, u.Remaining(), u.Limit)
+}
+
+// poll reads the status of a vehicle and emits its changes.
+func (w *Watcher) poll(ctx context.Context, v *Vehicle) error {
+	prev := w.last[v]
+	var cur *VehicleStatus
+	status := func() (err error) {
+		cur, err = v.StatusContext(ctx)
+		return err
+	}
+	var err error
+	if w.client != nil {
+		err = w.client.Reauthenticate(ctx, status)
+	} else {
+		err = status()
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		return w.emit(ctx, StatusFailed{EventBase{Vehicle: v, Previous: prev, Current: prev}, err}, err)
+	}
+	w.last[v] = cur
+
+	base := EventBase{Vehicle: v, Previous: prev, Current: cur}
+	var events []Event
+	if prev != nil {
+		events = diffStatus(base, w.thresholds)
+	}
+	if w.updates {
+		events = append(events, StatusUpdated{base})
+	}
+	for _, e := range events {
+		if err := w.emit(ctx, e, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// emit delivers the event and returns err, or the context error if the
+// context is done first.
+func (w *Watcher) emit(ctx context.Context, e Event, err error) error {
+	select {
+	case w.events <- e:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// diffStatus derives the events between the previous and the current status
+// of a vehicle.
+func diffStatus(base EventBase, thresholds []int) []Event {
+	prev, cur := base.Previous, base.Current
+
+	var events []Event
+	switch {
+	case !prev.isCharging && cur.isCharging:
+		events = append(events, ChargingStarted{base})
+	case prev.isCharging && !cur.isCharging:
+		events = append(events, ChargingStopped{base})
+	}
+	switch {
+	case prev.doorIsLocked && !cur.doorIsLocked:
+		events = append(events, DoorUnlocked{base})
+	case !prev.doorIsLocked && cur.doorIsLocked:
+		events = append(events, DoorLocked{base})
+	}
+	switch {
+	case prev.plugState == 0 && cur.plugState != 0:
+		events = append(events, PluggedIn{base})
+	case prev.plugState != 0 && cur.plugState == 0:
+		events = append(events, Unplugged{base})
+	}
+	// thresholds are sorted, report them in the order they were passed
+	for _, t := range thresholds {
+		if prev.batterySoc < t && cur.batterySoc >= t {
+			events = append(events, SoCCrossed{base, t, true})
+		}
+	}
+	for i := len(thresholds) - 1; i >= 0; i-- {
+		if t := thresholds[i]; prev.batterySoc >= t && cur.batterySoc < t {
+			events = append(events, SoCCrossed{base, t, false})
+		}
+	}
+	return events
+}
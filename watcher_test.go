@@ -0,0 +1,172 @@
+package goblue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDiffStatus(t *testing.T) {
+	thresholds := []int{20, 50, 80}
+	tests := []struct {
+		name      string
+		prev, cur VehicleStatus
+		want      []string
+	}{
+		{"unchanged", VehicleStatus{batterySoc: 60}, VehicleStatus{batterySoc: 60}, nil},
+		{
+			name: "charging started and plugged in",
+			prev: VehicleStatus{batterySoc: 40},
+			cur:  VehicleStatus{batterySoc: 40, isCharging: true, plugState: 1},
+			want: []string{"ChargingStarted", "PluggedIn"},
+		},
+		{
+			name: "charging stopped and unplugged",
+			prev: VehicleStatus{isCharging: true, plugState: 2},
+			cur:  VehicleStatus{},
+			want: []string{"ChargingStopped", "Unplugged"},
+		},
+		{"unlocked", VehicleStatus{doorIsLocked: true}, VehicleStatus{}, []string{"DoorUnlocked"}},
+		{"locked", VehicleStatus{}, VehicleStatus{doorIsLocked: true}, []string{"DoorLocked"}},
+		{
+			name: "rising thresholds in ascending order",
+			prev: VehicleStatus{batterySoc: 10},
+			cur:  VehicleStatus{batterySoc: 85},
+			want: []string{"SoCCrossed 20 rising", "SoCCrossed 50 rising", "SoCCrossed 80 rising"},
+		},
+		{
+			name: "falling thresholds in descending order",
+			prev: VehicleStatus{batterySoc: 90},
+			cur:  VehicleStatus{batterySoc: 19},
+			want: []string{"SoCCrossed 80 falling", "SoCCrossed 50 falling", "SoCCrossed 20 falling"},
+		},
+		{"reaching a threshold", VehicleStatus{batterySoc: 49}, VehicleStatus{batterySoc: 50}, []string{"SoCCrossed 50 rising"}},
+		{"leaving a threshold", VehicleStatus{batterySoc: 50}, VehicleStatus{batterySoc: 49}, []string{"SoCCrossed 50 falling"}},
+		{"staying at a threshold", VehicleStatus{batterySoc: 80}, VehicleStatus{batterySoc: 80}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, e := range diffStatus(EventBase{Previous: &tt.prev, Current: &tt.cur}, thresholds) {
+				got = append(got, eventName(e))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func eventName(e Event) string {
+	switch e := e.(type) {
+	case SoCCrossed:
+		direction := "falling"
+		if e.Rising {
+			direction = "rising"
+		}
+		return fmt.Sprintf("SoCCrossed %d %s", e.Threshold, direction)
+	default:
+		return reflect.TypeOf(e).Name()
+	}
+}
+
+func TestWatcherRunCancelsStatus(t *testing.T) {
+	inFlight := make(chan struct{})
+	blocking := HttpClientFunc(func(req *http.Request) (*http.Response, error) {
+		close(inFlight)
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	})
+	v := NewVehicle("id", "vin", "name", vehicleTypeEV, BrandKia,
+		WithVehicleClient(blocking),
+		WithVehicleAuth(&auth{sess: session{AccessToken: "Bearer token"}}),
+		WithVehicleEndpoints(defaultEndpoints()),
+	)
+	w := NewWatcher([]*Vehicle{v})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- w.Run(ctx) }()
+
+	<-inFlight
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Run returned %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancelling Run did not interrupt the status request")
+	}
+	if _, ok := <-w.Events(); ok {
+		t.Error("event emitted for the cancelled request")
+	}
+
+	if err := w.Run(context.Background()); !errors.Is(err, ErrAlreadyRunning) {
+		t.Errorf("second Run returned %v, want ErrAlreadyRunning", err)
+	}
+}
+
+func TestWatcherSkipsPollsBeyondQuota(t *testing.T) {
+	body, err := os.ReadFile(filepath.Join("testdata", "status", "kia-eu-ev.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var polls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&polls, 1)
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(Config{Brand: BrandKia, Region: RegionEU}, WithBaseURL(srv.URL), WithDailyQuota(3, QuotaRefuse))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.auth.setSession(session{AccessToken: "Bearer token"})
+	var vs []*Vehicle
+	for _, id := range []string{"a", "b"} {
+		vs = append(vs, NewVehicle(id, "VIN-"+id, id, vehicleTypeEV, BrandKia,
+			WithVehicleClient(c.api), WithVehicleAuth(c.auth), WithVehicleEndpoints(c.endpoints)))
+	}
+
+	// the budget covers one round only
+	w := NewWatcher(vs, WithWatchClient(c), WithWatchInterval(10*time.Millisecond), WithStatusUpdates())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Run(ctx)
+
+	var got []string
+	for len(got) < 4 {
+		select {
+		case e := <-w.Events():
+			if f, ok := e.(StatusFailed); ok && !errors.Is(f.Err, ErrRateLimited) {
+				t.Fatalf("status failed with %v, want ErrRateLimited", f.Err)
+			}
+			got = append(got, eventName(e))
+		case <-time.After(5 * time.Second):
+			t.Fatalf("got events %v, want 4", got)
+		}
+	}
+	want := []string{"StatusUpdated", "StatusUpdated", "StatusFailed", "StatusFailed"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got events %v, want %v", got, want)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if n := atomic.LoadInt32(&polls); n != 2 {
+		t.Errorf("polled %d times, want 2", n)
+	}
+	if left := c.Quota().Remaining(); left != 1 {
+		t.Errorf("%d calls left, want 1", left)
+	}
+}